@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/mclellac/hurl/network"
+	"golang.org/x/term"
+)
+
+// parseUserArg splits a -u/--user argument into a username and password.
+// Without a colon the whole value is the username and the password is read
+// from the terminal without echoing it, like curl does.
+func parseUserArg(value string) (string, string, error) {
+	user, pass, hasPass := strings.Cut(value, ":")
+	if hasPass {
+		return user, pass, nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", "", fmt.Errorf("no password given for user %q and stdin is not a terminal", user)
+	}
+	fmt.Fprintf(os.Stderr, "Enter host password for user '%s': ", user)
+	password, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", "", fmt.Errorf("could not read password: %w", err)
+	}
+	return user, string(password), nil
+}
+
+// runBearerCommand runs command through the shell and returns its standard
+// output, trimmed of surrounding whitespace, as a bearer token. A failing
+// command is reported together with what it wrote to stderr. The command
+// reads from /dev/null, since hurl's own stdin may carry the request body.
+func runBearerCommand(command string) (string, error) {
+	cmd := exec.Command("sh", "-c", command)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if msg := strings.TrimSpace(stderr.String()); msg != "" && errors.As(err, &exitErr) {
+			return "", fmt.Errorf("%q failed (%v): %s", command, err, msg)
+		}
+		return "", fmt.Errorf("%q failed: %w", command, err)
+	}
+	token := strings.TrimSpace(stdout.String())
+	if token == "" {
+		return "", fmt.Errorf("%q printed no token", command)
+	}
+	if strings.ContainsAny(token, "\r\n") {
+		return "", fmt.Errorf("%q printed more than one line", command)
+	}
+	return token, nil
+}
+
+// loadNetrc reads the credentials file for --netrc/--netrc-file: path, or
+// ~/.netrc (~/_netrc on Windows) if path is empty. A missing default file
+// just means there are no credentials, so it returns nil; a missing
+// explicit file is an error. warn is called if others can read the file.
+func loadNetrc(path string, warn func(format string, args ...any)) (*network.Netrc, error) {
+	explicit := path != ""
+	if !explicit {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		name := ".netrc"
+		if runtime.GOOS == "windows" {
+			name = "_netrc"
+		}
+		path = filepath.Join(home, name)
+	}
+	netrc, insecure, err := network.LoadNetrc(path)
+	if errors.Is(err, fs.ErrNotExist) && !explicit {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if insecure && runtime.GOOS != "windows" {
+		warn("Warning: %s can be read by other users; consider chmod 600 %s", path, path)
+	}
+	return netrc, nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// conditionalHeaders builds the headers of a conditional request:
+// If-None-Match from the ETag stored in etagFile (--etag-compare), and
+// If-Modified-Since from timeCond (-z), which is an HTTP date or the name of
+// a file whose modification time is used. A leading '-' on timeCond asks
+// for If-Unmodified-Since instead.
+//
+// A missing ETag file or -z file adds no header, so the first run of a
+// polling loop simply fetches everything; warn is called for the -z case.
+func conditionalHeaders(etagFile, timeCond string, warn func(format string, args ...any)) ([]string, error) {
+	var headers []string
+	if etagFile != "" {
+		data, err := os.ReadFile(etagFile)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("--etag-compare: %w", err)
+		}
+		if etag := strings.TrimSpace(string(data)); etag != "" {
+			headers = append(headers, "If-None-Match: "+etag)
+		}
+	}
+
+	if timeCond != "" {
+		header := "If-Modified-Since"
+		value, unmodified := strings.CutPrefix(timeCond, "-")
+		if unmodified {
+			header = "If-Unmodified-Since"
+		}
+		t, err := parseTimeCond(value)
+		if err != nil {
+			return nil, fmt.Errorf("-z/--time-cond: %w", err)
+		}
+		if t.IsZero() {
+			warn("Warning: -z/--time-cond: %q is not a date or an existing file; sending an unconditional request", value)
+		} else {
+			headers = append(headers, header+": "+t.UTC().Format(http.TimeFormat))
+		}
+	}
+	return headers, nil
+}
+
+// parseTimeCond resolves a -z value: an HTTP date, an RFC 3339 time or date,
+// or a file whose modification time is used. It returns the zero time for
+// a name that is neither a date nor an existing file.
+func parseTimeCond(value string) (time.Time, error) {
+	if t, err := http.ParseTime(value); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{time.RFC3339, time.DateTime, time.DateOnly} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	fi, err := os.Stat(value)
+	if os.IsNotExist(err) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return fi.ModTime(), nil
+}
+
+// saveETag writes the ETag of resp to path (--etag-save), or an empty file
+// when the response has none. A 304 without an ETag leaves the file alone,
+// since the stored one is still current.
+func saveETag(path string, resp *http.Response) error {
+	etag := resp.Header.Get("ETag")
+	if etag == "" && resp.StatusCode == http.StatusNotModified {
+		return nil
+	}
+	if etag != "" {
+		etag += "\n"
+	}
+	return os.WriteFile(path, []byte(etag), 0666)
+}
+
+// setRemoteTime sets the modification time of the output file to the
+// response's Last-Modified date (-R), so a later -z on the same file asks
+// only for a newer copy. Responses without a valid date are ignored.
+func setRemoteTime(path string, resp *http.Response) error {
+	t, err := http.ParseTime(resp.Header.Get("Last-Modified"))
+	if err != nil {
+		return nil
+	}
+	return os.Chtimes(path, time.Now(), t)
+}
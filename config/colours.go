@@ -1,7 +1,14 @@
 // config/colours.go
 package config
 
-import "strings"
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
 
 // ANSI Color Codes
 const (
@@ -13,8 +20,19 @@ const (
 	ColorPurple = "\033[35m"
 	ColorCyan   = "\033[36m"
 	ColorWhite  = "\033[37m"
+
+	ColorBrightRed    = "\033[91m"
+	ColorBrightGreen  = "\033[92m"
+	ColorBrightYellow = "\033[93m"
+	ColorBrightBlue   = "\033[94m"
+	ColorBrightPurple = "\033[95m"
+	ColorBrightCyan   = "\033[96m"
+	ColorBrightWhite  = "\033[97m"
 )
 
+// boldPrefix is the modifier that renders a color in bold, e.g. "bold:cyan".
+const boldPrefix = "bold:"
+
 // DefaultColor is used if a configured color is invalid
 const DefaultColor = ColorCyan
 
@@ -28,14 +46,125 @@ var colorMap = map[string]string{
 	"purple": ColorPurple,
 	"cyan":   ColorCyan,
 	"white":  ColorWhite,
+
+	"bright-red":    ColorBrightRed,
+	"bright-green":  ColorBrightGreen,
+	"bright-yellow": ColorBrightYellow,
+	"bright-blue":   ColorBrightBlue,
+	"bright-purple": ColorBrightPurple,
+	"bright-cyan":   ColorBrightCyan,
+	"bright-white":  ColorBrightWhite,
 }
 
 // GetAnsiCode returns the ANSI code for a given color name.
 // It defaults to DefaultColor if the name is not recognized.
 func GetAnsiCode(name string) string {
-	code, ok := colorMap[strings.ToLower(name)]
-	if !ok {
+	code, err := parseColor(name)
+	if err != nil {
 		return DefaultColor // Return a default if color name is unknown
 	}
 	return code
 }
+
+// ValidateColor returns an error if name is not a recognized color spec.
+func ValidateColor(name string) error {
+	_, err := parseColor(name)
+	return err
+}
+
+// parseColor converts a color spec into an ANSI escape sequence. Besides the
+// names in colorMap it accepts "colorN" (N = 0-255) for the 256-color
+// palette and "#RRGGBB" for 24-bit colors. Any of these may be preceded by
+// "bold:", which is merged into the same SGR sequence.
+func parseColor(spec string) (string, error) {
+	name := strings.ToLower(strings.TrimSpace(spec))
+	if base, ok := strings.CutPrefix(name, boldPrefix); ok {
+		if base == "reset" {
+			return "", fmt.Errorf("%q cannot be combined with reset", boldPrefix)
+		}
+		code, err := parseBaseColor(base)
+		if err != nil {
+			return "", err
+		}
+		// "\033[36m" becomes "\033[1;36m".
+		return "\033[1;" + strings.TrimPrefix(code, "\033["), nil
+	}
+	return parseBaseColor(name)
+}
+
+// parseBaseColor parses a lowercased color spec without modifiers.
+func parseBaseColor(name string) (string, error) {
+	if code, ok := colorMap[name]; ok {
+		return code, nil
+	}
+
+	if index, ok := strings.CutPrefix(name, "color"); ok {
+		n, err := strconv.Atoi(index)
+		if err != nil || n < 0 || n > 255 {
+			return "", fmt.Errorf("invalid 256-color index in %q (use color0 to color255)", name)
+		}
+		return fmt.Sprintf("\033[38;5;%dm", n), nil
+	}
+
+	if hex, ok := strings.CutPrefix(name, "#"); ok {
+		rgb, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil || len(hex) != 6 {
+			return "", fmt.Errorf("invalid hex color %q (use #RRGGBB)", name)
+		}
+		return fmt.Sprintf("\033[38;2;%d;%d;%dm", rgb>>16, rgb>>8&0xff, rgb&0xff), nil
+	}
+
+	return "", fmt.Errorf("unknown color %q", name)
+}
+
+// GetAnsiCode returns the ANSI code for a given color name, or an empty
+// string when color output is disabled for this configuration.
+func (c Config) GetAnsiCode(name string) string {
+	if !c.Color {
+		return ""
+	}
+	return GetAnsiCode(name)
+}
+
+// HeaderValueCode returns the ANSI code for the value of the header key: the
+// color of the first matching HeaderColorRules pattern in sorted order, or
+// HeaderValueColor if none match. It is empty when color output is disabled.
+func (c Config) HeaderValueCode(key string) string {
+	if len(c.HeaderColorRules) > 0 {
+		patterns := make([]string, 0, len(c.HeaderColorRules))
+		for p := range c.HeaderColorRules {
+			patterns = append(patterns, p)
+		}
+		sort.Strings(patterns)
+
+		name := strings.ToLower(http.CanonicalHeaderKey(key))
+		for _, p := range patterns {
+			if ok, _ := path.Match(strings.ToLower(p), name); ok {
+				return c.GetAnsiCode(c.HeaderColorRules[p])
+			}
+		}
+	}
+	return c.GetAnsiCode(c.HeaderValueColor)
+}
+
+// StatusColor returns the ANSI code for an HTTP status code according to its
+// class, or an empty string when color output is disabled.
+func (c Config) StatusColor(code int) string {
+	switch {
+	case code >= 200 && code < 300:
+		return c.GetAnsiCode(c.StatusSuccessColor)
+	case code >= 300 && code < 400:
+		return c.GetAnsiCode(c.StatusRedirectColor)
+	default:
+		return c.GetAnsiCode(c.StatusErrorColor)
+	}
+}
+
+// ResetCode returns ColorReset, or an empty string when color output is
+// disabled for this configuration.
+func (c Config) ResetCode() string {
+	if !c.Color {
+		return ""
+	}
+	return ColorReset
+}
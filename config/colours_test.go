@@ -0,0 +1,93 @@
+package config
+
+import "testing"
+
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    string
+		wantErr string
+	}{
+		{"cyan", ColorCyan, ""},
+		{" Bright-Red ", ColorBrightRed, ""},
+		{"reset", ColorReset, ""},
+		{"bold:cyan", "\033[1;36m", ""},
+		{"BOLD:bright-white", "\033[1;97m", ""},
+		{"color0", "\033[38;5;0m", ""},
+		{"color208", "\033[38;5;208m", ""},
+		{"bold:color208", "\033[1;38;5;208m", ""},
+		{"#FF8800", "\033[38;2;255;136;0m", ""},
+		{"bold:#00ff00", "\033[1;38;2;0;255;0m", ""},
+		{"color256", "", `invalid 256-color index in "color256" (use color0 to color255)`},
+		{"color-1", "", `invalid 256-color index in "color-1" (use color0 to color255)`},
+		{"color", "", `invalid 256-color index in "color" (use color0 to color255)`},
+		{"#12345", "", `invalid hex color "#12345" (use #RRGGBB)`},
+		{"#gggggg", "", `invalid hex color "#gggggg" (use #RRGGBB)`},
+		{"magenta", "", `unknown color "magenta"`},
+		{"", "", `unknown color ""`},
+		{"bold:reset", "", `"bold:" cannot be combined with reset`},
+		{"bold:nope", "", `unknown color "nope"`},
+	}
+	for _, tt := range tests {
+		got, err := parseColor(tt.spec)
+		if tt.wantErr != "" {
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("parseColor(%q) error = %v, want %q", tt.spec, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("parseColor(%q) = %q, %v; want %q", tt.spec, got, err, tt.want)
+		}
+	}
+}
+
+func TestGetAnsiCode(t *testing.T) {
+	if got := GetAnsiCode("nope"); got != DefaultColor {
+		t.Errorf("GetAnsiCode of an unknown color = %q, want DefaultColor", got)
+	}
+	cfg := DefaultConfig()
+	if got := cfg.GetAnsiCode("red"); got != ColorRed {
+		t.Errorf("Config.GetAnsiCode(red) = %q, want %q", got, ColorRed)
+	}
+	cfg.Color = false
+	if got := cfg.GetAnsiCode("red") + cfg.ResetCode(); got != "" {
+		t.Errorf("Config.GetAnsiCode with colors off = %q, want empty", got)
+	}
+}
+
+func TestHeaderValueCode(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.HeaderValueColor = "white"
+	cfg.HeaderColorRules = map[string]string{
+		"X-*":             "red",
+		"x-request-*":     "green",
+		"content-type":    "yellow",
+		"Set-Cookie":      "purple",
+		"access-control*": "blue",
+	}
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"X-Powered-By", ColorRed},
+		{"x-powered-by", ColorRed},
+		// "X-*" sorts before "x-request-*", so it wins.
+		{"X-Request-Id", ColorRed},
+		{"CONTENT-TYPE", ColorYellow},
+		{"set-cookie", ColorPurple},
+		{"Access-Control-Allow-Origin", ColorBlue},
+		{"Content-Length", ColorWhite},
+		{"Server", ColorWhite},
+	}
+	for _, tt := range tests {
+		if got := cfg.HeaderValueCode(tt.key); got != tt.want {
+			t.Errorf("HeaderValueCode(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+
+	cfg.Color = false
+	if got := cfg.HeaderValueCode("X-Powered-By"); got != "" {
+		t.Errorf("HeaderValueCode with colors off = %q, want empty", got)
+	}
+}
@@ -3,15 +3,41 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
+	"strings"
 )
 
 // Config defines the structure for our configuration file.
 type Config struct {
-	HeaderKeyColor   string `json:"header_key_color"`
-	HeaderValueColor string `json:"header_value_color"`
+	HeaderKeyColor   string `json:"header_key_color" yaml:"header_key_color" toml:"header_key_color"`
+	HeaderValueColor string `json:"header_value_color" yaml:"header_value_color" toml:"header_value_color"`
+
+	// Status code colors, chosen by the class of the response status.
+	StatusSuccessColor  string `json:"status_success_color" yaml:"status_success_color" toml:"status_success_color"`    // 2xx
+	StatusRedirectColor string `json:"status_redirect_color" yaml:"status_redirect_color" toml:"status_redirect_color"` // 3xx
+	StatusErrorColor    string `json:"status_error_color" yaml:"status_error_color" toml:"status_error_color"`          // Everything else (1xx, 4xx, 5xx)
+
+	// HeaderColorRules maps header-name patterns (path.Match syntax, matched
+	// case-insensitively, e.g. "Set-Cookie" or "X-*") to value colors that
+	// override HeaderValueColor.
+	HeaderColorRules map[string]string `json:"header_color_rules" yaml:"header_color_rules" toml:"header_color_rules"`
+
+	// DefaultHeaders are sent with every request, in the same "Key: Value"
+	// format as -H. Headers given with -H replace them.
+	DefaultHeaders []string `json:"default_headers" yaml:"default_headers" toml:"default_headers"`
+
+	// SplitHeaderValues prints each value of a repeated header on its own
+	// line with the key repeated, instead of joining them with ", ".
+	SplitHeaderValues bool `json:"split_header_values" yaml:"split_header_values" toml:"split_header_values"`
+
+	// Color reports whether ANSI colors are emitted. It is not read from the
+	// config file; the CLI resolves it from --color for each output stream.
+	Color bool `json:"-" yaml:"-" toml:"-"`
 }
 
 // DefaultConfig returns the default configuration settings.
@@ -19,72 +45,255 @@ func DefaultConfig() Config {
 	return Config{
 		HeaderKeyColor:   "yellow", // Default key color
 		HeaderValueColor: "cyan",   // Default value color
+
+		StatusSuccessColor:  "green",
+		StatusRedirectColor: "yellow",
+		StatusErrorColor:    "red",
+
+		Color: true,
 	}
 }
 
-// LoadConfig loads configuration from a JSON file.
-// If the file doesn't exist or is invalid, it returns default settings.
-func LoadConfig() (Config, error) {
-	cfg := DefaultConfig() // Start with defaults
+// Errors returned by LoadConfig. Test for them with errors.Is.
+var (
+	// ErrConfigNotFound means the given config file does not exist, or
+	// there is no user config directory to look for the default one in.
+	ErrConfigNotFound = errors.New("config file not found")
+	// ErrConfigDecode means the config file could not be parsed or holds
+	// invalid values. Every *FileError matches it.
+	ErrConfigDecode = errors.New("invalid config file")
+)
+
+// configFileNames are tried in order in the hurl config directory; the
+// extension selects the format.
+var configFileNames = []string{"config.json", "config.yaml", "config.yml", "config.toml"}
 
+// DefaultConfigPath returns the location of config.json in the user config
+// directory.
+func DefaultConfigPath() (string, error) {
 	configDir, err := os.UserConfigDir()
 	if err != nil {
-		// Fallback if user config dir is not available
-		fmt.Fprintf(os.Stderr, "Warning: Could not find user config directory: %v. Using default colors.\n", err)
-		return cfg, nil // Not a fatal error, just use defaults
+		return "", fmt.Errorf("could not find user config directory: %w", err)
 	}
+	return filepath.Join(configDir, "hurl", "config.json"), nil
+}
 
-	configPath := filepath.Join(configDir, "hurl", "config.json")
+// LoadConfig loads configuration from the file at configPath, or from the
+// first of config.json, config.yaml, config.yml and config.toml found in the
+// user config directory when configPath is empty. Files ending in .yaml or
+// .yml are read as YAML, .toml as TOML and anything else as JSON. A missing
+// default file is not an error and yields the default settings; an
+// explicitly given file must exist, or the error matches ErrConfigNotFound.
+//
+// LoadConfig prints nothing. The Config it returns is always usable: a file
+// that cannot be read or parsed yields the defaults, and invalid values are
+// replaced by their defaults. In lenient mode every such problem is still
+// returned, as a *FileError (matching ErrConfigDecode) or, for several,
+// errors.Join of them, for the caller to report as warnings. With strict
+// set, unknown fields are rejected too, and only the first problem is
+// returned, with the default settings.
+func LoadConfig(configPath string, strict bool) (Config, error) {
+	cfg := DefaultConfig() // Start with defaults
+
+	explicit := configPath != ""
+	if !explicit {
+		defaultPath, err := DefaultConfigPath()
+		if err != nil {
+			return cfg, fmt.Errorf("%w: %w", ErrConfigNotFound, err)
+		}
+		configPath = defaultPath
+		for _, name := range configFileNames {
+			candidate := filepath.Join(filepath.Dir(defaultPath), name)
+			if _, err := os.Stat(candidate); !os.IsNotExist(err) {
+				configPath = candidate
+				break
+			}
+		}
+	}
 
 	configFile, err := os.Open(configPath)
 	if err != nil {
-		if os.IsNotExist(err) {
+		switch {
+		case os.IsNotExist(err) && explicit:
+			return cfg, fmt.Errorf("%w: %w", ErrConfigNotFound, err)
+		case os.IsNotExist(err):
 			// Config file doesn't exist, which is fine. Use defaults.
-			// Optionally, you could create a default config file here.
-			// fmt.Fprintf(os.Stderr, "Info: Config file not found at %s. Using default colors.\n", configPath)
 			return cfg, nil
 		}
-		// Other error opening file
-		fmt.Fprintf(os.Stderr, "Warning: Error opening config file %s: %v. Using default colors.\n", configPath, err)
-		return cfg, nil // Use defaults on error
+		return cfg, fmt.Errorf("could not open config file: %w", err)
 	}
 	defer configFile.Close()
 
-	decoder := json.NewDecoder(configFile)
-	if err := decoder.Decode(&cfg); err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: Error decoding config file %s: %v. Using default colors.\n", configPath, err)
-		return DefaultConfig(), nil // Reset to defaults on decode error
+	if err := decodeConfig(configFile, configPath, strict, &cfg); err != nil {
+		return DefaultConfig(), &FileError{Path: configPath, Field: decodeErrorField(err), Err: err, Fallback: "Using default colors"}
+	}
+
+	// Basic validation: empty colors take the default, malformed values are
+	// dropped and reported. In strict mode only the first problem counts.
+	var problems []error
+	problem := func(field string, err error, fallback string) {
+		problems = append(problems, &FileError{Path: configPath, Field: field, Err: err, Fallback: fallback})
 	}
 
-	// Basic validation (ensure colors are not empty strings, etc.)
-	if cfg.HeaderKeyColor == "" {
-		cfg.HeaderKeyColor = DefaultConfig().HeaderKeyColor
+	def := DefaultConfig()
+	validateColorField("header_key_color", &cfg.HeaderKeyColor, def.HeaderKeyColor, problem)
+	validateColorField("header_value_color", &cfg.HeaderValueColor, def.HeaderValueColor, problem)
+	validateColorField("status_success_color", &cfg.StatusSuccessColor, def.StatusSuccessColor, problem)
+	validateColorField("status_redirect_color", &cfg.StatusRedirectColor, def.StatusRedirectColor, problem)
+	validateColorField("status_error_color", &cfg.StatusErrorColor, def.StatusErrorColor, problem)
+
+	patterns := make([]string, 0, len(cfg.HeaderColorRules))
+	for pattern := range cfg.HeaderColorRules {
+		patterns = append(patterns, pattern)
 	}
-	if cfg.HeaderValueColor == "" {
-		cfg.HeaderValueColor = DefaultConfig().HeaderValueColor
+	sort.Strings(patterns)
+	for _, pattern := range patterns {
+		field := fmt.Sprintf("header_color_rules[%q]", pattern)
+		if _, err := path.Match(pattern, ""); err != nil {
+			problem(field, fmt.Errorf("invalid pattern"), "Ignoring rule")
+			delete(cfg.HeaderColorRules, pattern)
+		} else if err := ValidateColor(cfg.HeaderColorRules[pattern]); err != nil {
+			problem(field, err, "Ignoring rule")
+			delete(cfg.HeaderColorRules, pattern)
+		}
 	}
 
-	return cfg, nil
+	headers := cfg.DefaultHeaders[:0]
+	for _, h := range cfg.DefaultHeaders {
+		if err := validateHeaderLine(h); err != nil {
+			problem("default_headers", err, "Ignoring header")
+			continue
+		}
+		headers = append(headers, h)
+	}
+	cfg.DefaultHeaders = headers
+
+	switch {
+	case len(problems) == 0:
+		return cfg, nil
+	case strict:
+		return DefaultConfig(), problems[0]
+	case len(problems) == 1:
+		return cfg, problems[0]
+	}
+	return cfg, errors.Join(problems...)
+}
+
+// FileError reports a config file that could not be parsed or an invalid
+// value in it.
+type FileError struct {
+	Path     string // Config file that was read
+	Field    string // JSON field at fault; empty if the file could not be parsed at all
+	Err      error
+	Fallback string // What LoadConfig did instead in lenient mode, e.g. "Ignoring rule"
+}
+
+func (e *FileError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("config file %s: %v", e.Path, e.Err)
+	}
+	return fmt.Sprintf("config file %s: %s: %v", e.Path, e.Field, e.Err)
+}
+
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+// Is makes every FileError match ErrConfigDecode.
+func (e *FileError) Is(target error) bool {
+	return target == ErrConfigDecode
+}
+
+// validateHeaderLine checks that h has the form "Key: Value" with a valid
+// header name.
+func validateHeaderLine(h string) error {
+	key, _, ok := strings.Cut(h, ":")
+	if !ok {
+		return fmt.Errorf("%q is not in \"Key: Value\" format", h)
+	}
+	key = strings.TrimSpace(key)
+	if key == "" || strings.ContainsAny(key, " \t") {
+		return fmt.Errorf("%q has an invalid header name", h)
+	}
+	return nil
+}
+
+// validateColorField resets an empty or malformed color spec to its default,
+// reporting the malformed case to problem.
+func validateColorField(field string, value *string, def string, problem func(field string, err error, fallback string)) {
+	if *value == "" {
+		*value = def
+		return
+	}
+	if err := ValidateColor(*value); err != nil {
+		problem(field, err, fmt.Sprintf("Using default color %q", def))
+		*value = def
+	}
+}
+
+// WriteDefaultConfig writes DefaultConfig to configPath (DefaultConfigPath
+// when empty) as an editable starting point and returns the file's path. An
+// existing file is only replaced when force is set.
+func WriteDefaultConfig(configPath string, force bool) (string, error) {
+	if configPath == "" {
+		if _, err := EnsureConfigDir(); err != nil {
+			return "", err
+		}
+		var err error
+		configPath, err = DefaultConfigPath()
+		if err != nil {
+			return "", err
+		}
+	}
+
+	// Empty collections are written out so every option is visible.
+	cfg := DefaultConfig()
+	cfg.HeaderColorRules = map[string]string{}
+	cfg.DefaultHeaders = []string{}
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	data = append(data, '\n')
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if force {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	f, err := os.OpenFile(configPath, flags, 0644)
+	if err != nil {
+		if os.IsExist(err) {
+			return "", fmt.Errorf("config file %s already exists (use --force to overwrite it)", configPath)
+		}
+		return "", err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return "", err
+	}
+	return configPath, f.Close()
 }
 
 // EnsureConfigDir checks if the config directory exists and creates it if not.
 // This can be called once at startup if you want to ensure the dir exists
-// for users to place their config file.
-func EnsureConfigDir() error {
+// for users to place their config file. It prints nothing; created is the
+// directory's path if it had to be created, for the caller to report, and
+// empty otherwise.
+func EnsureConfigDir() (created string, err error) {
 	configDir, err := os.UserConfigDir()
 	if err != nil {
-		return fmt.Errorf("could not find user config directory: %w", err)
+		return "", fmt.Errorf("could not find user config directory: %w", err)
 	}
 
 	hurlConfigDir := filepath.Join(configDir, "hurl")
 	if _, err := os.Stat(hurlConfigDir); os.IsNotExist(err) {
-		fmt.Fprintf(os.Stderr, "Info: Creating config directory: %s\n", hurlConfigDir)
 		err = os.MkdirAll(hurlConfigDir, 0750) // Read/write/execute for user, read/execute for group
 		if err != nil {
-			return fmt.Errorf("could not create config directory %s: %w", hurlConfigDir, err)
+			return "", fmt.Errorf("could not create config directory %s: %w", hurlConfigDir, err)
 		}
+		return hurlConfigDir, nil
 	} else if err != nil {
-		return fmt.Errorf("could not check config directory %s: %w", hurlConfigDir, err)
+		return "", fmt.Errorf("could not check config directory %s: %w", hurlConfigDir, err)
 	}
-	return nil
+	return "", nil
 }
@@ -0,0 +1,138 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWriteDefaultConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	written, err := WriteDefaultConfig(path, false)
+	if err != nil {
+		t.Fatalf("WriteDefaultConfig: %v", err)
+	}
+	if written != path {
+		t.Errorf("WriteDefaultConfig wrote %s, want %s", written, path)
+	}
+
+	cfg, err := LoadConfig(path, true)
+	if err != nil {
+		t.Fatalf("LoadConfig of the written file: %v", err)
+	}
+	want := DefaultConfig()
+	want.HeaderColorRules = map[string]string{}
+	want.DefaultHeaders = []string{}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("LoadConfig = %+v, want %+v", cfg, want)
+	}
+
+	if _, err := WriteDefaultConfig(path, false); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("second WriteDefaultConfig error = %v, want an \"already exists\" error", err)
+	}
+	if err := os.WriteFile(path, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := WriteDefaultConfig(path, true); err != nil {
+		t.Fatalf("WriteDefaultConfig with force: %v", err)
+	}
+	if data, _ := os.ReadFile(path); !strings.Contains(string(data), `"header_key_color": "yellow"`) {
+		t.Errorf("forced WriteDefaultConfig left %q", data)
+	}
+}
+
+func TestWriteDefaultConfigUserConfigDir(t *testing.T) {
+	// os.UserConfigDir uses $XDG_CONFIG_HOME, falling back to $HOME/.config.
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("HOME", dir)
+	want := filepath.Join(dir, "hurl", "config.json")
+
+	written, err := WriteDefaultConfig("", false)
+	if err != nil {
+		t.Fatalf("WriteDefaultConfig: %v", err)
+	}
+	if written != want {
+		t.Errorf("WriteDefaultConfig wrote %s, want %s", written, want)
+	}
+	if _, err := LoadConfig("", true); err != nil {
+		t.Errorf("LoadConfig of the default location: %v", err)
+	}
+
+	if err := os.WriteFile(want, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := WriteDefaultConfig("", false); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("second WriteDefaultConfig error = %v, want an \"already exists\" error", err)
+	}
+	if data, _ := os.ReadFile(want); string(data) != "{}" {
+		t.Errorf("WriteDefaultConfig without force replaced the file with %q", data)
+	}
+	if _, err := WriteDefaultConfig("", true); err != nil {
+		t.Fatalf("WriteDefaultConfig with force: %v", err)
+	}
+	if data, _ := os.ReadFile(want); !strings.Contains(string(data), `"header_key_color": "yellow"`) {
+		t.Errorf("forced WriteDefaultConfig left %q", data)
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	if _, err := LoadConfig(filepath.Join(dir, "missing.json"), false); !errors.Is(err, ErrConfigNotFound) {
+		t.Errorf("LoadConfig of a missing file error = %v, want ErrConfigNotFound", err)
+	}
+
+	cfg, err := LoadConfig(write("broken.json", "{"), false)
+	var fileErr *FileError
+	if !errors.Is(err, ErrConfigDecode) || !errors.As(err, &fileErr) || fileErr.Field != "" {
+		t.Errorf("LoadConfig of a broken file error = %#v, want a *FileError for the whole file", err)
+	}
+	if !reflect.DeepEqual(cfg, DefaultConfig()) {
+		t.Errorf("LoadConfig of a broken file = %+v, want the defaults", cfg)
+	}
+
+	bad := write("bad.yaml", "header_key_color: nope\nstatus_error_color: also-nope\ndefault_headers: ['X-Ok: 1', 'no colon']\n")
+	cfg, err = LoadConfig(bad, false)
+	if !errors.Is(err, ErrConfigDecode) {
+		t.Fatalf("lenient LoadConfig error = %v, want one matching ErrConfigDecode", err)
+	}
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok || len(joined.Unwrap()) != 3 {
+		t.Fatalf("lenient LoadConfig error = %v, want the 3 problems joined", err)
+	}
+	var fields []string
+	for _, e := range joined.Unwrap() {
+		if errors.As(e, &fileErr) {
+			fields = append(fields, fileErr.Field)
+		}
+	}
+	if want := []string{"header_key_color", "status_error_color", "default_headers"}; !reflect.DeepEqual(fields, want) {
+		t.Errorf("problem fields = %v, want %v", fields, want)
+	}
+	if cfg.HeaderKeyColor != "yellow" || !reflect.DeepEqual(cfg.DefaultHeaders, []string{"X-Ok: 1"}) {
+		t.Errorf("lenient LoadConfig = %+v, want the invalid values replaced or dropped", cfg)
+	}
+
+	cfg, err = LoadConfig(bad, true)
+	if !errors.As(err, &fileErr) || fileErr.Field != "header_key_color" {
+		t.Errorf("strict LoadConfig error = %v, want the first problem only", err)
+	}
+	if !reflect.DeepEqual(cfg, DefaultConfig()) {
+		t.Errorf("strict LoadConfig = %+v, want the defaults", cfg)
+	}
+
+	if _, err := LoadConfig(write("unknown.json", `{"header_key_colour": "blue"}`), true); !errors.As(err, &fileErr) || fileErr.Field != "header_key_colour" {
+		t.Errorf("strict LoadConfig of an unknown field error = %v", err)
+	}
+}
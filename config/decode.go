@@ -0,0 +1,80 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// decodeConfig decodes r into cfg in the format given by the extension of
+// configPath. In strict mode, keys that match no Config field are errors.
+func decodeConfig(r io.Reader, configPath string, strict bool, cfg *Config) error {
+	switch strings.ToLower(filepath.Ext(configPath)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(r)
+		decoder.KnownFields(strict)
+		if err := decoder.Decode(cfg); err != nil && err != io.EOF {
+			return err
+		}
+		return nil
+	case ".toml":
+		md, err := toml.NewDecoder(r).Decode(cfg)
+		if err != nil {
+			return err
+		}
+		if undecoded := md.Undecoded(); strict && len(undecoded) > 0 {
+			return &unknownFieldError{name: undecoded[0].String()}
+		}
+		return nil
+	default:
+		decoder := json.NewDecoder(r)
+		if strict {
+			decoder.DisallowUnknownFields()
+		}
+		return decoder.Decode(cfg)
+	}
+}
+
+// unknownFieldError reports a key that matches no Config field.
+type unknownFieldError struct {
+	name string
+}
+
+func (e *unknownFieldError) Error() string {
+	return fmt.Sprintf("unknown field %q", e.name)
+}
+
+// decodeErrorField returns the config field named by a decoding error, or ""
+// if the error is not about a particular field.
+func decodeErrorField(err error) string {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return typeErr.Field
+	}
+	var unknownErr *unknownFieldError
+	if errors.As(err, &unknownErr) {
+		return unknownErr.name
+	}
+	// DisallowUnknownFields reports `json: unknown field "name"`.
+	if name, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		if unquoted, err := strconv.Unquote(name); err == nil {
+			return unquoted
+		}
+	}
+	// yaml.v3 reports "line N: field name not found in type config.Config".
+	var yamlErr *yaml.TypeError
+	if errors.As(err, &yamlErr) && len(yamlErr.Errors) > 0 {
+		_, rest, ok := strings.Cut(yamlErr.Errors[0], ": field ")
+		if name, _, found := strings.Cut(rest, " not found"); ok && found {
+			return name
+		}
+	}
+	return ""
+}
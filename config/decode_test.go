@@ -0,0 +1,124 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+func TestDecodeConfigFormats(t *testing.T) {
+	want := Config{
+		HeaderKeyColor:    "blue",
+		HeaderColorRules:  map[string]string{"X-*": "red"},
+		DefaultHeaders:    []string{"Accept: text/plain"},
+		SplitHeaderValues: true,
+	}
+	tests := []struct {
+		path string
+		data string
+	}{
+		{"config.json", `{"header_key_color": "blue", "header_color_rules": {"X-*": "red"}, "default_headers": ["Accept: text/plain"], "split_header_values": true}`},
+		{"config.yaml", "header_key_color: blue\nheader_color_rules:\n  X-*: red\ndefault_headers:\n  - 'Accept: text/plain'\nsplit_header_values: true\n"},
+		{"config.yml", "header_key_color: blue\nheader_color_rules: {X-*: red}\ndefault_headers: ['Accept: text/plain']\nsplit_header_values: true\n"},
+		{"config.toml", "header_key_color = \"blue\"\ndefault_headers = [\"Accept: text/plain\"]\nsplit_header_values = true\n\n[header_color_rules]\n\"X-*\" = \"red\"\n"},
+		{"CONFIG.TOML", "header_key_color = \"blue\"\ndefault_headers = [\"Accept: text/plain\"]\nsplit_header_values = true\nheader_color_rules = {\"X-*\" = \"red\"}\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			var cfg Config
+			if err := decodeConfig(strings.NewReader(tt.data), tt.path, true, &cfg); err != nil {
+				t.Fatalf("decodeConfig: %v", err)
+			}
+			if !reflect.DeepEqual(cfg, want) {
+				t.Errorf("decodeConfig = %+v, want %+v", cfg, want)
+			}
+		})
+	}
+}
+
+func TestConfigRoundTrip(t *testing.T) {
+	// Every field differs from its default, so one a format cannot write
+	// or read back shows up as a difference.
+	want := Config{
+		HeaderKeyColor:      "blue",
+		HeaderValueColor:    "bright-green",
+		StatusSuccessColor:  "#00ff00",
+		StatusRedirectColor: "color214",
+		StatusErrorColor:    "bold:red",
+		HeaderColorRules:    map[string]string{"X-*": "red", "Set-Cookie": "purple"},
+		DefaultHeaders:      []string{"Accept: text/plain", "X-Trace: 1"},
+		SplitHeaderValues:   true,
+		Color:               true, // Not part of the file; LoadConfig keeps the default
+	}
+	encoders := map[string]func(v any) ([]byte, error){
+		"config.json": json.Marshal,
+		"config.yaml": yaml.Marshal,
+		"config.toml": func(v any) ([]byte, error) {
+			var b bytes.Buffer
+			err := toml.NewEncoder(&b).Encode(v)
+			return b.Bytes(), err
+		},
+	}
+	for name, encode := range encoders {
+		t.Run(name, func(t *testing.T) {
+			data, err := encode(want)
+			if err != nil {
+				t.Fatalf("encode: %v", err)
+			}
+			path := filepath.Join(t.TempDir(), name)
+			if err := os.WriteFile(path, data, 0644); err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := LoadConfig(path, true)
+			if err != nil {
+				t.Fatalf("LoadConfig of\n%s: %v", data, err)
+			}
+			if !reflect.DeepEqual(cfg, want) {
+				t.Errorf("LoadConfig of\n%s= %+v, want %+v", data, cfg, want)
+			}
+		})
+	}
+}
+
+func TestDecodeConfigEmptyYAML(t *testing.T) {
+	cfg := DefaultConfig()
+	if err := decodeConfig(strings.NewReader(""), "config.yaml", true, &cfg); err != nil {
+		t.Fatalf("decodeConfig of an empty file: %v", err)
+	}
+	if !reflect.DeepEqual(cfg, DefaultConfig()) {
+		t.Errorf("decodeConfig of an empty file changed the config to %+v", cfg)
+	}
+}
+
+func TestDecodeConfigUnknownField(t *testing.T) {
+	tests := []struct {
+		path string
+		data string
+	}{
+		{"config.json", `{"header_key_colour": "blue"}`},
+		{"config.yaml", "header_key_colour: blue\n"},
+		{"config.toml", "header_key_colour = \"blue\"\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			var cfg Config
+			if err := decodeConfig(strings.NewReader(tt.data), tt.path, false, &cfg); err != nil {
+				t.Errorf("lenient decodeConfig: %v", err)
+			}
+			err := decodeConfig(strings.NewReader(tt.data), tt.path, true, &cfg)
+			if err == nil {
+				t.Fatal("strict decodeConfig accepted an unknown field")
+			}
+			if field := decodeErrorField(err); field != "header_key_colour" {
+				t.Errorf("decodeErrorField(%v) = %q, want %q", err, field, "header_key_colour")
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	flag "github.com/spf13/pflag"
+)
+
+// loadConfigFile reads curl-style options from path ("-" for stdin) into fs
+// and returns the URLs it names. Each line holds one option, written as
+// "--header value", "-H value", "header = value" or "header: value"; values
+// with spaces are double-quoted, and blank lines and lines starting with '#'
+// are ignored. "url = ..." adds a URL to fetch.
+//
+// Options given on the command line take precedence: a single-valued flag
+// that is already set is left alone, while repeatable flags such as
+// --header collect the values from both places.
+func loadConfigFile(path string, fs *flag.FlagSet) ([]string, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	// Remember what the command line set before the file changes anything.
+	fromCommandLine := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { fromCommandLine[f.Name] = true })
+
+	var urls []string
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		name, rest := splitConfigOption(line)
+		value, hasValue, err := parseConfigValue(rest)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, lineNo, err)
+		}
+
+		if strings.TrimLeft(name, "-") == "url" {
+			if !hasValue {
+				return nil, fmt.Errorf("%s:%d: url needs a value", path, lineNo)
+			}
+			urls = append(urls, value)
+			continue
+		}
+
+		f := lookupConfigFlag(fs, name)
+		if f == nil {
+			return nil, fmt.Errorf("%s:%d: unknown option %q", path, lineNo, name)
+		}
+		if f.Name == "config" {
+			return nil, fmt.Errorf("%s:%d: --config cannot be nested", path, lineNo)
+		}
+		if !hasValue {
+			if f.NoOptDefVal == "" {
+				return nil, fmt.Errorf("%s:%d: option %q needs a value", path, lineNo, name)
+			}
+			value = f.NoOptDefVal
+		}
+		if fromCommandLine[f.Name] && !isRepeatableFlag(f) {
+			continue
+		}
+		if err := fs.Set(f.Name, value); err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, lineNo, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return urls, nil
+}
+
+// splitConfigOption splits a config line into the option name and the rest
+// of the line. Dashed options end at whitespace; bare names may also be
+// followed by '=' or ':'.
+func splitConfigOption(line string) (name, rest string) {
+	end := strings.IndexFunc(line, func(r rune) bool {
+		return r == ' ' || r == '\t' || (line[0] != '-' && (r == '=' || r == ':'))
+	})
+	if end < 0 {
+		return line, ""
+	}
+	rest = strings.TrimSpace(line[end:])
+	if line[0] != '-' && rest != "" && (rest[0] == '=' || rest[0] == ':') {
+		rest = strings.TrimSpace(rest[1:])
+	}
+	return line[:end], rest
+}
+
+// parseConfigValue parses the value part of a config line: a double-quoted
+// string with backslash escapes, or an unquoted word. hasValue is false when
+// the line has no value at all.
+func parseConfigValue(rest string) (value string, hasValue bool, err error) {
+	if rest == "" {
+		return "", false, nil
+	}
+	if rest[0] != '"' {
+		if i := strings.IndexAny(rest, " \t"); i >= 0 {
+			return "", false, fmt.Errorf("unexpected text after value: %q (quote values containing spaces)", rest[i:])
+		}
+		return rest, true, nil
+	}
+
+	var b strings.Builder
+	for i := 1; i < len(rest); i++ {
+		c := rest[i]
+		switch {
+		case c == '"':
+			if trailing := strings.TrimSpace(rest[i+1:]); trailing != "" {
+				return "", false, fmt.Errorf("unexpected text after quoted value: %q", trailing)
+			}
+			return b.String(), true, nil
+		case c == '\\' && i+1 < len(rest):
+			i++
+			switch rest[i] {
+			case 't':
+				b.WriteByte('\t')
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 'v':
+				b.WriteByte('\v')
+			default:
+				b.WriteByte(rest[i])
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", false, fmt.Errorf("unterminated quoted value")
+}
+
+// lookupConfigFlag finds the flag for a config option name: "--name",
+// "-x" or a bare long name.
+func lookupConfigFlag(fs *flag.FlagSet, name string) *flag.Flag {
+	switch {
+	case strings.HasPrefix(name, "--"):
+		return fs.Lookup(name[2:])
+	case strings.HasPrefix(name, "-"):
+		if len(name) != 2 {
+			return nil
+		}
+		return fs.ShorthandLookup(name[1:])
+	default:
+		return fs.Lookup(name)
+	}
+}
+
+// isRepeatableFlag reports whether each use of f adds a value rather than
+// replacing the previous one.
+func isRepeatableFlag(f *flag.Flag) bool {
+	t := f.Value.Type()
+	return strings.HasSuffix(t, "Array") || strings.HasSuffix(t, "Slice")
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	flag "github.com/spf13/pflag"
+)
+
+// configFlagSet returns a flag set with a few of hurl's options.
+func configFlagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet("hurl", flag.ContinueOnError)
+	fs.StringArrayP("header", "H", nil, "")
+	fs.StringP("request", "X", "GET", "")
+	fs.BoolP("location", "L", false, "")
+	fs.String("config-file", "", "")
+	fs.Bool("write-default-config", false, "")
+	fs.StringP("config", "K", "", "")
+	return fs
+}
+
+func writeConfigFile(t *testing.T, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "hurlrc")
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	path := writeConfigFile(t, `# Options
+--header "X-One: 1"
+-H "X-Two: two words"
+request = PUT
+location
+config-file: /etc/hurl/settings.yaml
+write-default-config
+url = https://example.com/a
+url = "https://example.com/b"
+`)
+	fs := configFlagSet()
+	if err := fs.Parse([]string{"-H", "X-Cli: 0", "-X", "DELETE"}); err != nil {
+		t.Fatal(err)
+	}
+	urls, err := loadConfigFile(path, fs)
+	if err != nil {
+		t.Fatalf("loadConfigFile: %v", err)
+	}
+	if want := []string{"https://example.com/a", "https://example.com/b"}; !reflect.DeepEqual(urls, want) {
+		t.Errorf("urls = %v, want %v", urls, want)
+	}
+
+	headers, _ := fs.GetStringArray("header")
+	if want := []string{"X-Cli: 0", "X-One: 1", "X-Two: two words"}; !reflect.DeepEqual(headers, want) {
+		t.Errorf("headers = %q, want %q", headers, want)
+	}
+	if method, _ := fs.GetString("request"); method != "DELETE" {
+		t.Errorf("request = %q, want the command line's DELETE", method)
+	}
+	if location, _ := fs.GetBool("location"); !location {
+		t.Error("location was not set from the file")
+	}
+	// main resolves the settings file after the merge, so these count.
+	if configFile, _ := fs.GetString("config-file"); configFile != "/etc/hurl/settings.yaml" || !fs.Changed("config-file") {
+		t.Errorf("config-file = %q (changed %v), want the file's value", configFile, fs.Changed("config-file"))
+	}
+	if write, _ := fs.GetBool("write-default-config"); !write {
+		t.Error("write-default-config was not set from the file")
+	}
+}
+
+func TestLoadConfigFileErrors(t *testing.T) {
+	tests := []struct {
+		data    string
+		wantErr string
+	}{
+		{"--nope 1", `:1: unknown option "--nope"`},
+		{"\n-H", `:2: option "-H" needs a value`},
+		{"-H X-A: 1", "unexpected text after value"},
+		{`-H "X-A: 1`, "unterminated quoted value"},
+		{"url", "url needs a value"},
+		{"config = other", "--config cannot be nested"},
+	}
+	for _, tt := range tests {
+		_, err := loadConfigFile(writeConfigFile(t, tt.data), configFlagSet())
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("loadConfigFile(%q) error = %v, want one containing %q", tt.data, err, tt.wantErr)
+		}
+	}
+}
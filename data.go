@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/mclellac/hurl/flagvar"
+)
+
+// dataBody assembles the request body from the data flags, joining the
+// values with "&" in the order given, and returns a function that yields a
+// fresh reader over it for every request. A lone "--data-binary @-" is
+// streamed from standard input, which sends the body chunked since its size
+// is unknown; as it can only be read once it is not resent on a 307 or 308
+// redirect. When replay is set because more than one request will be sent,
+// it is read up front like everything else, which gets a Content-Length.
+func dataBody(args flagvar.DataFlags, replay bool) (func() io.Reader, error) {
+	if len(args) == 1 && args[0].Kind == flagvar.DataBinary && args[0].Value == "@-" && !replay {
+		return func() io.Reader { return os.Stdin }, nil
+	}
+
+	parts := make([]string, len(args))
+	for i, arg := range args {
+		switch arg.Kind {
+		case flagvar.DataURLEncode:
+			part, err := urlencodeDataArg(arg.Value)
+			if err != nil {
+				return nil, err
+			}
+			parts[i] = part
+		default:
+			data, err := readDataArg(arg.Value, arg.Kind == flagvar.DataBinary)
+			if err != nil {
+				return nil, err
+			}
+			parts[i] = string(data)
+		}
+	}
+	data := strings.Join(parts, "&")
+	return func() io.Reader { return strings.NewReader(data) }, nil
+}
+
+// readDataArg resolves a data argument into raw request body bytes.
+// A value starting with '@' names a file whose contents are used instead;
+// "@-" reads standard input. Unless binary is set, carriage returns and
+// newlines are removed from file contents, as curl does for -d.
+func readDataArg(value string, binary bool) ([]byte, error) {
+	if !strings.HasPrefix(value, "@") {
+		return []byte(value), nil
+	}
+	data, err := readDataFile(strings.TrimPrefix(value, "@"))
+	if err != nil || binary {
+		return data, err
+	}
+	return bytes.ReplaceAll(bytes.ReplaceAll(data, []byte("\r"), nil), []byte("\n"), nil), nil
+}
+
+// readDataFile reads the named data file, or standard input if path is "-".
+// The data is read completely so the request gets a Content-Length.
+func readDataFile(path string) ([]byte, error) {
+	if path == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, fmt.Errorf("could not read data from stdin: %w", err)
+		}
+		return data, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read data file %s: %w", path, err)
+	}
+	return data, nil
+}
+
+// urlencodeDataArg resolves a --data-urlencode argument, following curl:
+//
+//	content     the whole content is URL-encoded
+//	=content    the content after "=" is URL-encoded
+//	name=value  only value is URL-encoded
+//	@file       the file contents (or stdin for "-") are URL-encoded
+//	name@file   the file contents are URL-encoded and sent as name's value
+func urlencodeDataArg(value string) (string, error) {
+	i := strings.IndexAny(value, "=@")
+	if i < 0 {
+		return url.QueryEscape(value), nil
+	}
+
+	name, content := value[:i], value[i+1:]
+	if value[i] == '@' {
+		data, err := readDataFile(content)
+		if err != nil {
+			return "", err
+		}
+		content = string(data)
+	}
+	if name == "" {
+		return url.QueryEscape(content), nil
+	}
+	return name + "=" + url.QueryEscape(content), nil
+}
+
+// graphqlBody wraps a GraphQL query, and optional JSON variables, in the
+// {"query": ..., "variables": ...} envelope that GraphQL servers accept
+// over POST.
+func graphqlBody(query string, variables []byte) ([]byte, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, fmt.Errorf("the --graphql query is empty")
+	}
+	envelope := struct {
+		Query     string          `json:"query"`
+		Variables json.RawMessage `json:"variables,omitempty"`
+	}{Query: query}
+	if len(bytes.TrimSpace(variables)) > 0 {
+		if !json.Valid(variables) {
+			return nil, fmt.Errorf("the --graphql-vars variables are not valid JSON")
+		}
+		if trimmed := bytes.TrimSpace(variables); trimmed[0] != '{' {
+			return nil, fmt.Errorf("the --graphql-vars variables must be a JSON object")
+		}
+		envelope.Variables = variables
+	}
+	return json.Marshal(envelope)
+}
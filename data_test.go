@@ -0,0 +1,143 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mclellac/hurl/flagvar"
+)
+
+// withStdin runs f with standard input replaced by a pipe fed with data.
+func withStdin(t *testing.T, data string, f func()) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		w.WriteString(data)
+		w.Close()
+	}()
+	stdin := os.Stdin
+	os.Stdin = r
+	defer func() {
+		os.Stdin = stdin
+		r.Close()
+	}()
+	f()
+}
+
+func TestDataBodyFromStdin(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   flagvar.DataFlags
+		replay bool
+		want   string
+	}{
+		{"data strips newlines", flagvar.DataFlags{{Kind: flagvar.DataASCII, Value: "@-"}}, false, "a=1b=2"},
+		{"binary streamed", flagvar.DataFlags{{Kind: flagvar.DataBinary, Value: "@-"}}, false, "a=1\nb=2\n"},
+		{"binary replayed", flagvar.DataFlags{{Kind: flagvar.DataBinary, Value: "@-"}}, true, "a=1\nb=2\n"},
+		{"joined with other data", flagvar.DataFlags{{Kind: flagvar.DataBinary, Value: "@-"}, {Kind: flagvar.DataASCII, Value: "c=3"}}, false, "a=1\nb=2\n&c=3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withStdin(t, "a=1\nb=2\n", func() {
+				makeBody, err := dataBody(tt.args, tt.replay)
+				if err != nil {
+					t.Fatalf("dataBody: %v", err)
+				}
+				body, err := io.ReadAll(makeBody())
+				if err != nil || string(body) != tt.want {
+					t.Errorf("body = %q, %v; want %q", body, err, tt.want)
+				}
+			})
+		})
+	}
+}
+
+func TestDataBodyFromStdinReplaysForEveryRequest(t *testing.T) {
+	withStdin(t, "payload", func() {
+		makeBody, err := dataBody(flagvar.DataFlags{{Kind: flagvar.DataBinary, Value: "@-"}}, true)
+		if err != nil {
+			t.Fatalf("dataBody: %v", err)
+		}
+		for i := range 3 {
+			body, err := io.ReadAll(makeBody())
+			if err != nil || string(body) != "payload" {
+				t.Errorf("request %d body = %q, %v; want %q", i+1, body, err, "payload")
+			}
+		}
+	})
+}
+
+func TestGraphqlBody(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		variables string
+		want      string
+		wantErr   string
+	}{
+		{"query only", "{ viewer { login } }", "", `{"query":"{ viewer { login } }"}`, ""},
+		{"with variables", "query($id: ID!) { node(id: $id) { id } }", ` {"id": "42"} `, `{"query":"query($id: ID!) { node(id: $id) { id } }","variables":{"id":"42"}}`, ""},
+		{"blank variables", "{ a }", " \n", `{"query":"{ a }"}`, ""},
+		{"empty query", "  \n", "", "", "query is empty"},
+		{"invalid variables", "{ a }", "{id: 1}", "", "not valid JSON"},
+		{"variables not an object", "{ a }", "[1, 2]", "", "must be a JSON object"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := graphqlBody(tt.query, []byte(tt.variables))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("graphqlBody error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || string(body) != tt.want {
+				t.Errorf("graphqlBody = %s, %v; want %s", body, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestUrlencodeDataArg(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "data.txt")
+	if err := os.WriteFile(file, []byte("a b&c=d\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		arg  string
+		want string
+	}{
+		{"hello world", "hello+world"},
+		{"a&b", "a%26b"},
+		{"=a=b c", "a%3Db+c"},
+		{"name=a b&c", "name=a+b%26c"},
+		{"name=", "name="},
+		{"@" + file, "a+b%26c%3Dd%0A"},
+		{"name@" + file, "name=a+b%26c%3Dd%0A"},
+	}
+	for _, tt := range tests {
+		got, err := urlencodeDataArg(tt.arg)
+		if err != nil || got != tt.want {
+			t.Errorf("urlencodeDataArg(%q) = %q, %v; want %q", tt.arg, got, err, tt.want)
+		}
+	}
+
+	withStdin(t, "x y", func() {
+		if got, err := urlencodeDataArg("name@-"); err != nil || got != "name=x+y" {
+			t.Errorf("urlencodeDataArg(name@-) = %q, %v; want %q", got, err, "name=x+y")
+		}
+	})
+
+	missing := filepath.Join(t.TempDir(), "missing.txt")
+	for _, arg := range []string{"@" + missing, "name@" + missing} {
+		if _, err := urlencodeDataArg(arg); err == nil || !strings.Contains(err.Error(), "could not read data file "+missing) {
+			t.Errorf("urlencodeDataArg(%q) error = %v, want a read error", arg, err)
+		}
+	}
+}
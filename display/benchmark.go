@@ -0,0 +1,70 @@
+package display
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/mclellac/hurl/config"
+)
+
+// PrintBenchmarkHeader prints the column headings of the --repeat table.
+func PrintBenchmarkHeader(w io.Writer, cfg config.Config) {
+	fmt.Fprintf(w, "%s%5s  %-6s  %10s  %12s%s\n",
+		cfg.GetAnsiCode(cfg.HeaderKeyColor), "#", "Status", "Size", "Time", cfg.ResetCode())
+}
+
+// PrintBenchmarkRow prints one request of the --repeat table: its number,
+// response status, body size and total time.
+func PrintBenchmarkRow(w io.Writer, n, status int, size int64, d time.Duration, cfg config.Config) {
+	valueColor := cfg.GetAnsiCode(cfg.HeaderValueColor)
+	resetColor := cfg.ResetCode()
+	fmt.Fprintf(w, "%s%5d%s  %s%-6d%s  %s%10s  %12s%s\n",
+		valueColor, n, resetColor,
+		cfg.StatusColor(status), status, resetColor,
+		valueColor, formatBytes(float64(size)), d.Round(time.Microsecond), resetColor)
+}
+
+// PrintBenchmarkSummary prints the number of requests and the minimum,
+// average, median, 90th and 99th percentile and maximum of times, which
+// holds the durations of the requests that succeeded.
+func PrintBenchmarkSummary(w io.Writer, times []time.Duration, failed int, cfg config.Config) {
+	keyColor := cfg.GetAnsiCode(cfg.HeaderKeyColor)
+	valueColor := cfg.GetAnsiCode(cfg.HeaderValueColor)
+	resetColor := cfg.ResetCode()
+
+	fmt.Fprintf(w, "\n%s%-10s%s %s%d (%d failed)%s\n", keyColor, "Requests:", resetColor, valueColor, len(times)+failed, failed, resetColor)
+	if len(times) == 0 {
+		return
+	}
+
+	sorted := slices.Clone(times)
+	slices.Sort(sorted)
+	var sum time.Duration
+	for _, d := range sorted {
+		sum += d
+	}
+
+	stats := []struct {
+		name     string
+		duration time.Duration
+	}{
+		{"Min", sorted[0]},
+		{"Avg", sum / time.Duration(len(sorted))},
+		{"p50", percentile(sorted, 50)},
+		{"p90", percentile(sorted, 90)},
+		{"p99", percentile(sorted, 99)},
+		{"Max", sorted[len(sorted)-1]},
+	}
+	for _, s := range stats {
+		fmt.Fprintf(w, "%s%-10s%s %s%s%s\n", keyColor, s.name+":", resetColor, valueColor, s.duration.Round(time.Microsecond), resetColor)
+	}
+}
+
+// percentile returns the p-th percentile of sorted by the nearest-rank
+// method.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100 // ceil(p/100 * n)
+	return sorted[max(rank, 1)-1]
+}
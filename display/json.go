@@ -0,0 +1,112 @@
+package display
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mclellac/hurl/network"
+)
+
+// Exchange is the document written by --json-output. Its field names are
+// part of hurl's interface; add fields rather than renaming them.
+type Exchange struct {
+	Request   ExchangeRequest    `json:"request"`
+	Response  ExchangeResponse   `json:"response"`
+	Timings   ExchangeTimings    `json:"timings"`
+	Redirects []ExchangeRedirect `json:"redirects"`
+}
+
+// ExchangeRequest describes the final request that was sent.
+type ExchangeRequest struct {
+	Method  string              `json:"method"`
+	URL     string              `json:"url"`
+	Headers map[string][]string `json:"headers"`
+}
+
+// ExchangeResponse describes the response. Body holds the body as text when
+// it is valid UTF-8 and base64-encoded otherwise, as told by BodyEncoding.
+type ExchangeResponse struct {
+	Status       int                 `json:"status"`
+	StatusText   string              `json:"status_text"`
+	Proto        string              `json:"proto"`
+	Headers      map[string][]string `json:"headers"`
+	Body         string              `json:"body"`
+	BodyEncoding string              `json:"body_encoding"` // "utf-8" or "base64"
+}
+
+// ExchangeRedirect describes one redirect that was followed.
+type ExchangeRedirect struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Status int    `json:"status"`
+}
+
+// ExchangeTimings holds the phase timings in seconds.
+type ExchangeTimings struct {
+	DNS       float64 `json:"dns"`
+	Connect   float64 `json:"connect"`
+	TLS       float64 `json:"tls"`
+	FirstByte float64 `json:"first_byte"`
+	Total     float64 `json:"total"`
+}
+
+// NewExchange builds the --json-output document for resp, whose body has
+// already been read into body.
+func NewExchange(resp *http.Response, body []byte, t network.Timings, info network.TransferInfo) Exchange {
+	_, statusText, _ := strings.Cut(resp.Status, " ")
+	ex := Exchange{
+		Response: ExchangeResponse{
+			Status:       resp.StatusCode,
+			StatusText:   statusText,
+			Proto:        resp.Proto,
+			Headers:      headerMap(resp.Header),
+			Body:         string(body),
+			BodyEncoding: "utf-8",
+		},
+		Timings: ExchangeTimings{
+			DNS:       t.DNS.Seconds(),
+			Connect:   t.Connect.Seconds(),
+			TLS:       t.TLS.Seconds(),
+			FirstByte: t.FirstByte.Seconds(),
+			Total:     t.Total.Seconds(),
+		},
+		Redirects: make([]ExchangeRedirect, 0, len(info.Hops)),
+	}
+	for _, hop := range info.Hops {
+		ex.Redirects = append(ex.Redirects, ExchangeRedirect{From: hop.From, To: hop.To, Status: hop.Status})
+	}
+	if !utf8.Valid(body) {
+		ex.Response.Body = base64.StdEncoding.EncodeToString(body)
+		ex.Response.BodyEncoding = "base64"
+	}
+	if req := resp.Request; req != nil {
+		ex.Request = ExchangeRequest{
+			Method:  req.Method,
+			URL:     req.URL.String(),
+			Headers: headerMap(info.RequestHeaders),
+		}
+	}
+	return ex
+}
+
+// WriteExchange writes ex to w as indented JSON followed by a newline.
+func WriteExchange(w io.Writer, ex Exchange) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(ex)
+}
+
+// headerMap converts headers to a plain map, so that missing headers are
+// written as {} rather than null.
+func headerMap(h http.Header) map[string][]string {
+	m := make(map[string][]string, len(h))
+	for k, v := range h {
+		m[k] = v
+	}
+	return m
+}
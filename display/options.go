@@ -0,0 +1,88 @@
+package display
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/mclellac/hurl/config"
+)
+
+// corsHeaders are the CORS response headers shown by PrintOptions, in
+// display order. Those holding lists are shown one value per line.
+var corsHeaders = []struct {
+	name string
+	list bool
+}{
+	{"Access-Control-Allow-Origin", false},
+	{"Access-Control-Allow-Methods", true},
+	{"Access-Control-Allow-Headers", true},
+	{"Access-Control-Allow-Credentials", false},
+	{"Access-Control-Expose-Headers", true},
+	{"Access-Control-Max-Age", false},
+}
+
+// PrintOptions summarizes the response to an OPTIONS request: the status
+// line, each method listed in Allow, and the CORS headers, with allowed
+// methods highlighted in the success color.
+func PrintOptions(w io.Writer, resp *http.Response, cfg config.Config) {
+	keyColor := cfg.GetAnsiCode(cfg.HeaderKeyColor)
+	valueColor := cfg.GetAnsiCode(cfg.HeaderValueColor)
+	methodColor := cfg.GetAnsiCode(cfg.StatusSuccessColor)
+	resetColor := cfg.ResetCode()
+
+	statusCode, statusText, _ := strings.Cut(resp.Status, " ")
+	fmt.Fprintf(w, "%s%s%s %s%s%s %s%s%s\n\n",
+		valueColor, resp.Proto, resetColor,
+		cfg.StatusColor(resp.StatusCode), statusCode, resetColor,
+		valueColor, statusText, resetColor)
+
+	fmt.Fprintf(w, "%sAllowed methods:%s\n", keyColor, resetColor)
+	methods := splitList(resp.Header.Values("Allow"))
+	if len(methods) == 0 {
+		fmt.Fprintf(w, "  %s(no Allow header)%s\n", valueColor, resetColor)
+	}
+	for _, m := range methods {
+		fmt.Fprintf(w, "  %s%s%s\n", methodColor, m, resetColor)
+	}
+
+	fmt.Fprintf(w, "\n%sCORS:%s\n", keyColor, resetColor)
+	found := false
+	for _, h := range corsHeaders {
+		values := resp.Header.Values(h.name)
+		if len(values) == 0 {
+			continue
+		}
+		found = true
+		if !h.list {
+			fmt.Fprintf(w, "  %s%s:%s %s%s%s\n", keyColor, h.name, resetColor, valueColor, strings.Join(values, ", "), resetColor)
+			continue
+		}
+		fmt.Fprintf(w, "  %s%s:%s\n", keyColor, h.name, resetColor)
+		color := valueColor
+		if h.name == "Access-Control-Allow-Methods" {
+			color = methodColor
+		}
+		for _, v := range splitList(values) {
+			fmt.Fprintf(w, "    %s%s%s\n", color, v, resetColor)
+		}
+	}
+	if !found {
+		fmt.Fprintf(w, "  %s(no CORS headers)%s\n", valueColor, resetColor)
+	}
+}
+
+// splitList splits comma-separated header values into their trimmed,
+// non-empty items.
+func splitList(values []string) []string {
+	var items []string
+	for _, v := range values {
+		for item := range strings.SplitSeq(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+	}
+	return items
+}
@@ -0,0 +1,73 @@
+package display
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"strings"
+
+	"github.com/mclellac/hurl/config"
+)
+
+// IsJSONContentType reports whether a Content-Type header value denotes JSON,
+// either application/json or a structured "+json" suffix type.
+func IsJSONContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// PrintJSON re-indents a JSON body and prints it to the specified writer,
+// colouring object keys and string values like header keys and values.
+// If the body isn't valid JSON it is written verbatim.
+func PrintJSON(w io.Writer, body []byte, cfg config.Config) {
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, body, "", "  "); err != nil {
+		w.Write(body)
+		return
+	}
+
+	keyColor := cfg.GetAnsiCode(cfg.HeaderKeyColor)
+	valueColor := cfg.GetAnsiCode(cfg.HeaderValueColor)
+	resetColor := cfg.ResetCode()
+
+	// json.Indent keeps whitespace after the value, such as the newline most
+	// APIs end their bodies with; only one newline is written below.
+	out := bytes.TrimRight(indented.Bytes(), " \t\r\n")
+	var buf bytes.Buffer
+	for i := 0; i < len(out); i++ {
+		if out[i] != '"' {
+			buf.WriteByte(out[i])
+			continue
+		}
+
+		// Find the closing quote, skipping escaped characters.
+		end := i + 1
+		for end < len(out) && out[end] != '"' {
+			if out[end] == '\\' {
+				end++
+			}
+			end++
+		}
+
+		// A string followed by a colon is an object key.
+		next := end + 1
+		for next < len(out) && out[next] == ' ' {
+			next++
+		}
+		color := valueColor
+		if next < len(out) && out[next] == ':' {
+			color = keyColor
+		}
+
+		buf.WriteString(color)
+		buf.Write(out[i : end+1])
+		buf.WriteString(resetColor)
+		i = end
+	}
+	buf.WriteByte('\n')
+	w.Write(buf.Bytes())
+}
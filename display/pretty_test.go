@@ -0,0 +1,32 @@
+package display
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/mclellac/hurl/config"
+)
+
+func TestPrintJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"compact", `{"a":[1,"x"]}`, "{\n  \"a\": [\n    1,\n    \"x\"\n  ]\n}\n"},
+		{"trailing newline", "{\"a\":1}\n", "{\n  \"a\": 1\n}\n"},
+		{"trailing CRLF and spaces", "[1] \r\n", "[\n  1\n]\n"},
+		{"invalid", "{not json\n", "{not json\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			cfg.Color = false
+			var b bytes.Buffer
+			PrintJSON(&b, []byte(tt.body), cfg)
+			if b.String() != tt.want {
+				t.Errorf("PrintJSON(%q) = %q, want %q", tt.body, b.String(), tt.want)
+			}
+		})
+	}
+}
@@ -12,11 +12,11 @@ import (
 )
 
 // PrintHeaders takes HTTP headers and configuration, then prints them
-// to the specified writer with configured colors.
+// to the specified writer with configured colors. Keys are sorted; multiple
+// values are joined with ", " unless cfg.SplitHeaderValues is set.
 func PrintHeaders(w io.Writer, headers http.Header, cfg config.Config) {
-	keyColor := config.GetAnsiCode(cfg.HeaderKeyColor)
-	valueColor := config.GetAnsiCode(cfg.HeaderValueColor)
-	resetColor := config.ColorReset
+	keyColor := cfg.GetAnsiCode(cfg.HeaderKeyColor)
+	resetColor := cfg.ResetCode()
 
 	keys := make([]string, 0, len(headers))
 	for k := range headers {
@@ -26,14 +26,18 @@ func PrintHeaders(w io.Writer, headers http.Header, cfg config.Config) {
 
 	for _, k := range keys {
 		values := headers[k]
-		valueStr := strings.Join(values, ", ")
-		fmt.Fprintf(w, "%s%s:%s %s%s%s\n",
-			keyColor,
-			k,
-			resetColor,
-			valueColor,
-			valueStr,
-			resetColor,
-		)
+		if !cfg.SplitHeaderValues {
+			values = []string{strings.Join(values, ", ")}
+		}
+		for _, v := range values {
+			fmt.Fprintf(w, "%s%s:%s %s%s%s\n",
+				keyColor,
+				k,
+				resetColor,
+				cfg.HeaderValueCode(k),
+				v,
+				resetColor,
+			)
+		}
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,58 @@
+package display
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/mclellac/hurl/config"
+)
+
+func TestPrintHeaders(t *testing.T) {
+	headers := http.Header{
+		"Set-Cookie":   {"a=1", "b=2"},
+		"Content-Type": {"text/plain"},
+		"X-Empty":      {""},
+	}
+	tests := []struct {
+		name  string
+		split bool
+		want  string
+	}{
+		{
+			name: "joined",
+			want: "Content-Type: text/plain\nSet-Cookie: a=1, b=2\nX-Empty: \n",
+		},
+		{
+			name:  "split",
+			split: true,
+			want:  "Content-Type: text/plain\nSet-Cookie: a=1\nSet-Cookie: b=2\nX-Empty: \n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			cfg.Color = false
+			cfg.SplitHeaderValues = tt.split
+			var b bytes.Buffer
+			PrintHeaders(&b, headers, cfg)
+			if b.String() != tt.want {
+				t.Errorf("PrintHeaders = %q, want %q", b.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestPrintHeadersColor(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.HeaderKeyColor = "blue"
+	cfg.HeaderValueColor = "green"
+	cfg.HeaderColorRules = map[string]string{"Set-*": "red"}
+	var b bytes.Buffer
+	PrintHeaders(&b, http.Header{"Server": {"x"}, "Set-Cookie": {"a=1"}}, cfg)
+	want := config.ColorBlue + "Server:" + config.ColorReset + " " + config.ColorGreen + "x" + config.ColorReset + "\n" +
+		config.ColorBlue + "Set-Cookie:" + config.ColorReset + " " + config.ColorRed + "a=1" + config.ColorReset + "\n"
+	if b.String() != want {
+		t.Errorf("PrintHeaders = %q, want %q", b.String(), want)
+	}
+}
@@ -0,0 +1,125 @@
+package display
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// progressInterval is how often the progress line is redrawn.
+const progressInterval = 200 * time.Millisecond
+
+// progressBarWidth is the number of cells in the progress bar.
+const progressBarWidth = 30
+
+// spinnerFrames are shown in turn when the total size is unknown.
+var spinnerFrames = []string{"|", "/", "-", "\\"}
+
+// Progress is an io.Writer that counts the bytes written to it and redraws
+// a one-line progress display on a timer: a bar with percentage, bytes and
+// throughput when the total size is known, or a spinner with a running
+// byte count when it is not. Call Finish when the transfer is done.
+type Progress struct {
+	w     io.Writer
+	total int64 // Expected number of bytes; negative if unknown
+	start time.Time
+
+	mu    sync.Mutex
+	n     int64
+	frame int
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewProgress starts a progress display on w for a transfer of total bytes
+// (negative if unknown).
+func NewProgress(w io.Writer, total int64) *Progress {
+	p := &Progress{
+		w:     w,
+		total: total,
+		start: time.Now(),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+// Write records len(b) transferred bytes. It never fails.
+func (p *Progress) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	p.n += int64(len(b))
+	p.mu.Unlock()
+	return len(b), nil
+}
+
+// Finish stops the timer, draws the final state and ends the line.
+func (p *Progress) Finish() {
+	close(p.stop)
+	<-p.done
+	p.draw()
+	fmt.Fprintln(p.w)
+}
+
+// run redraws the progress line until Finish is called.
+func (p *Progress) run() {
+	defer close(p.done)
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			p.draw()
+		case <-p.stop:
+			return
+		}
+	}
+}
+
+// draw overwrites the current line with the progress so far.
+func (p *Progress) draw() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	elapsed := time.Since(p.start).Seconds()
+	rate := 0.0
+	if elapsed > 0 {
+		rate = float64(p.n) / elapsed
+	}
+
+	if p.total < 0 {
+		frame := spinnerFrames[p.frame%len(spinnerFrames)]
+		p.frame++
+		fmt.Fprintf(p.w, "\r%s %10s  %10s/s\033[K", frame, formatBytes(float64(p.n)), formatBytes(rate))
+		return
+	}
+
+	fraction := 1.0
+	if p.total > 0 {
+		fraction = min(float64(p.n)/float64(p.total), 1)
+	}
+	filled := int(fraction * progressBarWidth)
+	bar := strings.Repeat("=", filled)
+	if filled < progressBarWidth {
+		bar += ">" + strings.Repeat(" ", progressBarWidth-filled-1)
+	}
+	fmt.Fprintf(p.w, "\r%3.0f%% [%s] %10s / %-10s %10s/s\033[K",
+		fraction*100, bar, formatBytes(float64(p.n)), formatBytes(float64(p.total)), formatBytes(rate))
+}
+
+// formatBytes formats a byte count with a binary unit, e.g. "1.5 MiB".
+func formatBytes(n float64) string {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB"}
+	i := 0
+	for n >= 1024 && i < len(units)-1 {
+		n /= 1024
+		i++
+	}
+	if i == 0 {
+		return fmt.Sprintf("%.0f %s", n, units[i])
+	}
+	return fmt.Sprintf("%.1f %s", n, units[i])
+}
@@ -0,0 +1,55 @@
+package display
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/mclellac/hurl/config"
+	"github.com/mclellac/hurl/network"
+)
+
+// PrintTimings prints the duration of each request phase to the specified
+// writer with configured colors.
+func PrintTimings(w io.Writer, t network.Timings, cfg config.Config) {
+	keyColor := cfg.GetAnsiCode(cfg.HeaderKeyColor)
+	valueColor := cfg.GetAnsiCode(cfg.HeaderValueColor)
+	resetColor := cfg.ResetCode()
+
+	phases := []struct {
+		name     string
+		duration time.Duration
+	}{
+		{"DNS lookup", t.DNS},
+		{"TCP connect", t.Connect},
+		{"TLS handshake", t.TLS},
+		{"Time to first byte", t.FirstByte},
+		{"Total", t.Total},
+	}
+	for _, p := range phases {
+		fmt.Fprintf(w, "%s%-19s%s %s%s%s\n",
+			keyColor,
+			p.name+":",
+			resetColor,
+			valueColor,
+			p.duration.Round(time.Microsecond),
+			resetColor,
+		)
+	}
+}
+
+// PrintSummary prints the closing line of a verbose transfer: how many body
+// bytes were sent and received, how long it took, and the URL the final
+// response came from.
+func PrintSummary(w io.Writer, result *network.Result, cfg config.Config) {
+	traceColor := cfg.GetAnsiCode("white")
+	valueColor := cfg.GetAnsiCode(cfg.HeaderValueColor)
+	resetColor := cfg.ResetCode()
+	fmt.Fprintf(w, "%s* Sent %s%d%s bytes, received %s%d%s bytes in %s%s%s from %s%s%s\n",
+		traceColor,
+		valueColor, result.Info.BytesSent, traceColor,
+		valueColor, result.Info.BytesReceived, traceColor,
+		valueColor, result.Timings.Total.Round(time.Microsecond), traceColor,
+		valueColor, result.EffectiveURL, resetColor,
+	)
+}
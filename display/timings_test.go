@@ -0,0 +1,26 @@
+package display
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mclellac/hurl/config"
+	"github.com/mclellac/hurl/network"
+)
+
+func TestPrintSummary(t *testing.T) {
+	result := &network.Result{
+		EffectiveURL: "https://example.com/final",
+		Timings:      network.Timings{Total: 1500 * time.Millisecond},
+		Info:         network.TransferInfo{BytesSent: 10, BytesReceived: 2048},
+	}
+	cfg := config.DefaultConfig()
+	cfg.Color = false
+	var b strings.Builder
+	PrintSummary(&b, result, cfg)
+	want := "* Sent 10 bytes, received 2048 bytes in 1.5s from https://example.com/final\n"
+	if b.String() != want {
+		t.Errorf("PrintSummary = %q, want %q", b.String(), want)
+	}
+}
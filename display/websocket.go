@@ -0,0 +1,43 @@
+package display
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/mclellac/hurl/config"
+)
+
+// PrintWebSocketProbe reports the outcome of a WebSocket handshake: the
+// status line and response headers, then whether the upgrade was accepted
+// (problem is nil) and, if so, the negotiated subprotocol and extensions.
+func PrintWebSocketProbe(w io.Writer, resp *http.Response, problem error, cfg config.Config) {
+	keyColor := cfg.GetAnsiCode(cfg.HeaderKeyColor)
+	valueColor := cfg.GetAnsiCode(cfg.HeaderValueColor)
+	resetColor := cfg.ResetCode()
+
+	statusCode, statusText, _ := strings.Cut(resp.Status, " ")
+	fmt.Fprintf(w, "%s%s%s %s%s%s %s%s%s\n",
+		valueColor, resp.Proto, resetColor,
+		cfg.StatusColor(resp.StatusCode), statusCode, resetColor,
+		valueColor, statusText, resetColor)
+	PrintHeaders(w, resp.Header, cfg)
+	fmt.Fprintln(w)
+
+	if problem != nil {
+		fmt.Fprintf(w, "%sWebSocket upgrade rejected:%s %s\n", cfg.GetAnsiCode(cfg.StatusErrorColor), resetColor, problem)
+		return
+	}
+	fmt.Fprintf(w, "%sWebSocket upgrade accepted%s\n", cfg.GetAnsiCode(cfg.StatusSuccessColor), resetColor)
+	for _, field := range []struct{ name, header string }{
+		{"Subprotocol", "Sec-WebSocket-Protocol"},
+		{"Extensions", "Sec-WebSocket-Extensions"},
+	} {
+		value := strings.Join(resp.Header.Values(field.header), ", ")
+		if value == "" {
+			value = "(none)"
+		}
+		fmt.Fprintf(w, "  %s%s:%s %s%s%s\n", keyColor, field.name, resetColor, valueColor, value, resetColor)
+	}
+}
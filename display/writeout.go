@@ -0,0 +1,127 @@
+package display
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/mclellac/hurl/network"
+)
+
+// WriteOutData holds the values available to --write-out variables.
+type WriteOutData struct {
+	Response     *http.Response       // Final response
+	Timings      network.Timings      // Captured phase timings
+	Info         network.TransferInfo // Connection and redirect details
+	SizeDownload int64                // Number of body bytes received
+}
+
+// writeOutVars maps the supported %{name} variables to their values.
+var writeOutVars = map[string]func(d WriteOutData) string{
+	"http_code": func(d WriteOutData) string {
+		return fmt.Sprintf("%03d", d.Response.StatusCode)
+	},
+	"url_effective": func(d WriteOutData) string {
+		if d.Response.Request == nil {
+			return ""
+		}
+		return d.Response.Request.URL.String()
+	},
+	"size_download": func(d WriteOutData) string {
+		return strconv.FormatInt(d.SizeDownload, 10)
+	},
+	"content_type": func(d WriteOutData) string {
+		return d.Response.Header.Get("Content-Type")
+	},
+	"time_total": func(d WriteOutData) string {
+		return fmt.Sprintf("%.6f", d.Timings.Total.Seconds())
+	},
+	"remote_ip": func(d WriteOutData) string {
+		host, _ := splitAddr(d.Info.RemoteAddr)
+		return host
+	},
+	"remote_port": func(d WriteOutData) string {
+		_, port := splitAddr(d.Info.RemoteAddr)
+		return port
+	},
+	"local_ip": func(d WriteOutData) string {
+		host, _ := splitAddr(d.Info.LocalAddr)
+		return host
+	},
+	"local_port": func(d WriteOutData) string {
+		_, port := splitAddr(d.Info.LocalAddr)
+		return port
+	},
+	"num_redirects": func(d WriteOutData) string {
+		return strconv.Itoa(len(d.Info.Hops))
+	},
+	"cert_expiry_days": func(d WriteOutData) string {
+		if len(d.Info.PeerCerts) == 0 {
+			return ""
+		}
+		return strconv.Itoa(network.DaysUntilExpiry(d.Info.PeerCerts[0]))
+	},
+}
+
+// WriteOut expands a curl-style --write-out format string and writes the
+// result to w. Variables look like %{http_code}; the escapes \n, \r, \t and \\
+// are interpreted. Unknown variables are written literally and their names
+// are returned so the caller can warn about them.
+func WriteOut(w io.Writer, format string, data WriteOutData) []string {
+	var b strings.Builder
+	var unknown []string
+
+	for i := 0; i < len(format); i++ {
+		c := format[i]
+		switch {
+		case c == '\\' && i+1 < len(format):
+			switch format[i+1] {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case '\\':
+				b.WriteByte('\\')
+			default:
+				b.WriteByte(c)
+				continue
+			}
+			i++
+		case c == '%' && strings.HasPrefix(format[i:], "%{"):
+			end := strings.IndexByte(format[i:], '}')
+			if end < 0 {
+				b.WriteString(format[i:])
+				i = len(format)
+				continue
+			}
+			name := format[i+2 : i+end]
+			if value, ok := writeOutVars[name]; ok {
+				b.WriteString(value(data))
+			} else {
+				b.WriteString(format[i : i+end+1])
+				unknown = append(unknown, name)
+			}
+			i += end
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	io.WriteString(w, b.String())
+	return unknown
+}
+
+// splitAddr splits an ip:port address; an address without a port (such as
+// a Unix socket path) is returned whole as the host.
+func splitAddr(addr string) (host, port string) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr, ""
+	}
+	return host, port
+}
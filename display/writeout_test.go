@@ -0,0 +1,55 @@
+package display
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mclellac/hurl/network"
+)
+
+func TestWriteOutAddresses(t *testing.T) {
+	data := WriteOutData{
+		Response: &http.Response{StatusCode: 200, Header: http.Header{}},
+		Info: network.TransferInfo{
+			RemoteAddr: "[2001:db8::1]:443",
+			LocalAddr:  "192.0.2.10:50123",
+			Hops:       []network.RedirectHop{{Status: 301}, {Status: 302}},
+		},
+	}
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"%{remote_ip} %{remote_port}", "2001:db8::1 443"},
+		{"%{local_ip}:%{local_port}\\n", "192.0.2.10:50123\n"},
+		{"%{http_code}\\t%{num_redirects}", "200\t2"},
+	}
+	for _, tt := range tests {
+		var b strings.Builder
+		if unknown := WriteOut(&b, tt.format, data); len(unknown) != 0 {
+			t.Errorf("WriteOut(%q) reported unknown variables %v", tt.format, unknown)
+		}
+		if b.String() != tt.want {
+			t.Errorf("WriteOut(%q) = %q, want %q", tt.format, b.String(), tt.want)
+		}
+	}
+}
+
+func TestWriteOutUnixSocketAddress(t *testing.T) {
+	data := WriteOutData{Response: &http.Response{Header: http.Header{}}, Info: network.TransferInfo{RemoteAddr: "/run/app.sock"}}
+	var b strings.Builder
+	WriteOut(&b, "%{remote_ip}|%{remote_port}", data)
+	if b.String() != "/run/app.sock|" {
+		t.Errorf("WriteOut = %q, want the socket path and no port", b.String())
+	}
+}
+
+func TestWriteOutUnknownVariable(t *testing.T) {
+	var b strings.Builder
+	unknown := WriteOut(&b, "%{remote_ip}%{nope}", WriteOutData{Response: &http.Response{Header: http.Header{}}})
+	if !reflect.DeepEqual(unknown, []string{"nope"}) || b.String() != "%{nope}" {
+		t.Errorf("WriteOut = %q, unknown %v; want %q, [nope]", b.String(), unknown, "%{nope}")
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// expandHeaderVars replaces $NAME and ${NAME} in each header with the value
+// of the environment variable, and "$$" with a literal '$'. It also returns
+// the names of the variables that are not set, which expand to nothing.
+func expandHeaderVars(headers []string) (expanded, undefined []string) {
+	expanded = make([]string, len(headers))
+	for i, h := range headers {
+		expanded[i] = os.Expand(h, func(name string) string {
+			if name == "$" {
+				return "$"
+			}
+			value, ok := os.LookupEnv(name)
+			if !ok && !slices.Contains(undefined, name) {
+				undefined = append(undefined, name)
+			}
+			return value
+		})
+	}
+	return expanded, undefined
+}
+
+// expandHeaders expands the variables in the -H headers unless noExpand
+// (--no-expand) is set. Unset variables are an error when strict (--strict-expand)
+// is set; otherwise their names are returned so the caller can warn.
+func expandHeaders(headers []string, noExpand, strict bool) (expanded, undefined []string, err error) {
+	if noExpand {
+		return headers, nil, nil
+	}
+	expanded, undefined = expandHeaderVars(headers)
+	if len(undefined) > 0 && strict {
+		return nil, nil, fmt.Errorf("-H refers to unset environment variable %s", strings.Join(undefined, ", "))
+	}
+	return expanded, undefined, nil
+}
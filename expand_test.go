@@ -0,0 +1,84 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExpandHeaderVars(t *testing.T) {
+	t.Setenv("HURL_TOKEN", "abc")
+	t.Setenv("HURL_EMPTY", "")
+	tests := []struct {
+		header    string
+		want      string
+		undefined []string
+	}{
+		{"Authorization: Bearer $HURL_TOKEN", "Authorization: Bearer abc", nil},
+		{"X-Id: ${HURL_TOKEN}-1", "X-Id: abc-1", nil},
+		{"X-Price: $$5 and $$HURL_TOKEN", "X-Price: $5 and $HURL_TOKEN", nil},
+		{"X-Empty: [$HURL_EMPTY]", "X-Empty: []", nil},
+		{"X-Unset: [$HURL_UNSET]", "X-Unset: []", []string{"HURL_UNSET"}},
+		{"X-Both: [$HURL_UNSET][${HURL_OTHER}][$HURL_UNSET]", "X-Both: [][][]", []string{"HURL_UNSET", "HURL_OTHER"}},
+		{"X-Plain: no variables", "X-Plain: no variables", nil},
+	}
+	for _, tt := range tests {
+		expanded, undefined := expandHeaderVars([]string{tt.header})
+		if expanded[0] != tt.want || !reflect.DeepEqual(undefined, tt.undefined) {
+			t.Errorf("expandHeaderVars(%q) = %q, %q; want %q, %q", tt.header, expanded[0], undefined, tt.want, tt.undefined)
+		}
+	}
+}
+
+func TestExpandHeaders(t *testing.T) {
+	t.Setenv("HURL_TOKEN", "abc")
+	headers := []string{"X-Token: $HURL_TOKEN", "X-A: $HURL_UNSET_A", "X-B: ${HURL_UNSET_B}"}
+	tests := []struct {
+		name      string
+		noExpand  bool
+		strict    bool
+		want      []string
+		undefined []string
+		wantErr   string
+	}{
+		{
+			name:      "expand",
+			want:      []string{"X-Token: abc", "X-A: ", "X-B: "},
+			undefined: []string{"HURL_UNSET_A", "HURL_UNSET_B"},
+		},
+		{
+			name:    "strict",
+			strict:  true,
+			wantErr: "-H refers to unset environment variable HURL_UNSET_A, HURL_UNSET_B",
+		},
+		{
+			name:     "no expand",
+			noExpand: true,
+			want:     headers,
+		},
+		{
+			name:     "no expand wins over strict",
+			noExpand: true,
+			strict:   true,
+			want:     headers,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, undefined, err := expandHeaders(headers, tt.noExpand, tt.strict)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(got, tt.want) || !reflect.DeepEqual(undefined, tt.undefined) {
+				t.Errorf("expandHeaders = %q, %q, %v; want %q, %q", got, undefined, err, tt.want, tt.undefined)
+			}
+		})
+	}
+
+	got, _, err := expandHeaders([]string{"X-Strict: $HURL_TOKEN"}, false, true)
+	if want := []string{"X-Strict: abc"}; err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("strict expansion of set variables = %q, %v; want %q", got, err, want)
+	}
+}
@@ -0,0 +1,60 @@
+package flagvar
+
+import (
+	"fmt"
+
+	flag "github.com/spf13/pflag"
+)
+
+// DataKind says how a data argument is turned into request body bytes.
+type DataKind int
+
+const (
+	DataASCII     DataKind = iota // -d/--data
+	DataBinary                    // --data-binary
+	DataURLEncode                 // --data-urlencode
+)
+
+// DataArg is one data argument together with the kind of flag it came from.
+type DataArg struct {
+	Kind  DataKind
+	Value string
+}
+
+// DataFlags collects the values of all data flags in the order they were
+// given, so that -d, --data-binary and --data-urlencode can be interleaved
+// like in curl.
+type DataFlags []DataArg
+
+// Flag returns a pflag.Value that appends arguments of the given kind.
+func (d *DataFlags) Flag(kind DataKind) flag.Value {
+	return &dataFlag{args: d, kind: kind}
+}
+
+// dataFlag is the pflag.Value for one of the data flags.
+type dataFlag struct {
+	args *DataFlags
+	kind DataKind
+}
+
+// String returns a string representation of the values of this kind.
+func (f *dataFlag) String() string {
+	var values []string
+	for _, arg := range *f.args {
+		if arg.Kind == f.kind {
+			values = append(values, arg.Value)
+		}
+	}
+	return fmt.Sprintf("%v", values)
+}
+
+// Set appends a value to the collection. Called by flag.Parse() for each flag instance.
+func (f *dataFlag) Set(value string) error {
+	*f.args = append(*f.args, DataArg{Kind: f.kind, Value: value})
+	return nil
+}
+
+// Type returns the type description for pflag.
+func (f *dataFlag) Type() string {
+	return "stringArray"
+}
@@ -2,7 +2,10 @@
 package flagvar
 
 import (
+	"bufio"
 	"fmt"
+	"os"
+	"strings"
 )
 
 // HeaderFlags implements pflag.Value interface for collecting multiple flag strings.
@@ -15,17 +18,74 @@ func (h *HeaderFlags) String() string {
 }
 
 // Set appends a value to the collection. Called by flag.Parse() for each flag instance.
+// A value of the form "@file" appends every header in the file instead.
 func (h *HeaderFlags) Set(value string) error {
+	if name, ok := strings.CutPrefix(value, "@"); ok {
+		headers, err := readHeaderFile(name)
+		if err != nil {
+			return err
+		}
+		*h = append(*h, headers...)
+		return nil
+	}
 	*h = append(*h, value)
 	return nil
 }
 
-// Type returns the type description for pflag.
+// Type returns the type description for pflag. It names a slice type so
+// that help output and -K config files treat the flag as repeatable.
 func (h *HeaderFlags) Type() string {
-	return "stringSlice" // Added required Type() method
+	return "stringSlice"
 }
 
 // Get returns the collected flag values as a slice of strings.
 func (h *HeaderFlags) Get() []string {
 	return *h
-}
\ No newline at end of file
+}
+
+// readHeaderFile reads one header per line from name, skipping blank lines
+// and lines starting with '#'. Every header must be "Key: Value" (or "Key;"
+// for an empty value).
+func readHeaderFile(name string) ([]string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var headers []string
+	scanner := bufio.NewScanner(f)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !validHeaderLine(line) {
+			return nil, fmt.Errorf("%s:%d: malformed header %q (expected \"Key: Value\")", name, lineNo, line)
+		}
+		headers = append(headers, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return headers, nil
+}
+
+// validHeaderLine reports whether line is "Key: Value" or "Key;" with a key
+// made of HTTP token characters.
+func validHeaderLine(line string) bool {
+	key, _, ok := strings.Cut(line, ":")
+	if !ok {
+		key, ok = strings.CutSuffix(line, ";")
+	}
+	key = strings.TrimSpace(key)
+	if !ok || key == "" {
+		return false
+	}
+	for _, r := range key {
+		if r > 0x7e || r <= ' ' || strings.ContainsRune("\"(),/:;<=>?@[\\]{}", r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,76 @@
+package flagvar
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidHeaderLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want bool
+	}{
+		{"Accept: text/html", true},
+		{"X-Custom-Header:value", true},
+		{"Accept:", true},
+		{"X-Empty;", true},
+		{"  X-Padded : v", true},
+		{"Weird!#$%&'*+-.^_`|~Key: v", true},
+		{"no separator", false},
+		{": value", false},
+		{";", false},
+		{"Bad Key: v", false},
+		{"Bad/Key: v", false},
+		{"Bad(Key): v", false},
+		{"Ключ: v", false},
+		{"X-Semi;extra", false},
+	}
+	for _, tt := range tests {
+		if got := validHeaderLine(tt.line); got != tt.want {
+			t.Errorf("validHeaderLine(%q) = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestHeaderFlagsFromFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "headers.txt")
+	content := "# auth\nAuthorization: Bearer abc\n\n  X-Trace: 1  \r\nX-Empty;\n"
+	if err := os.WriteFile(file, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var h HeaderFlags
+	for _, arg := range []string{"Accept: */*", "@" + file, "X-Last: 2"} {
+		if err := h.Set(arg); err != nil {
+			t.Fatalf("Set(%q): %v", arg, err)
+		}
+	}
+	want := []string{"Accept: */*", "Authorization: Bearer abc", "X-Trace: 1", "X-Empty;", "X-Last: 2"}
+	if got := h.Get(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Get() = %q, want %q", got, want)
+	}
+}
+
+func TestHeaderFlagsFromFileErrors(t *testing.T) {
+	dir := t.TempDir()
+	bad := filepath.Join(dir, "bad.txt")
+	if err := os.WriteFile(bad, []byte("Accept: */*\n# ok\nnot a header\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	var h HeaderFlags
+	err := h.Set("@" + bad)
+	if want := bad + `:3: malformed header "not a header" (expected "Key: Value")`; err == nil || err.Error() != want {
+		t.Errorf("Set(@bad) error = %v, want %q", err, want)
+	}
+	if len(h) != 0 {
+		t.Errorf("Set(@bad) appended %q", h)
+	}
+
+	missing := filepath.Join(dir, "missing.txt")
+	if err := h.Set("@" + missing); err == nil || !strings.Contains(err.Error(), missing) {
+		t.Errorf("Set(@missing) error = %v, want one naming the file", err)
+	}
+}
@@ -0,0 +1,49 @@
+package flagvar
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Pair is one key=value argument.
+type Pair struct {
+	Key   string
+	Value string
+}
+
+// MapFlags implements pflag.Value for repeatable key=value flags. Unlike a
+// map it keeps every argument, duplicates included, in the order given.
+type MapFlags []Pair
+
+// String returns a string representation of the collected pairs.
+func (m *MapFlags) String() string {
+	values := make([]string, len(*m))
+	for i, p := range *m {
+		values[i] = p.Key + "=" + p.Value
+	}
+	return fmt.Sprintf("%v", values)
+}
+
+// Set splits a key=value argument at the first '=' and appends it. Called
+// by flag.Parse() for each flag instance.
+func (m *MapFlags) Set(value string) error {
+	key, v, ok := strings.Cut(value, "=")
+	if !ok {
+		return fmt.Errorf("missing '=' in %q (expected key=value)", value)
+	}
+	if key == "" {
+		return fmt.Errorf("missing key before '=' in %q", value)
+	}
+	*m = append(*m, Pair{Key: key, Value: v})
+	return nil
+}
+
+// Type returns the type description for pflag.
+func (m *MapFlags) Type() string {
+	return "stringArray"
+}
+
+// Get returns the collected pairs.
+func (m *MapFlags) Get() []Pair {
+	return *m
+}
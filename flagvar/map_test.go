@@ -0,0 +1,42 @@
+package flagvar
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMapFlags(t *testing.T) {
+	var m MapFlags
+	for _, arg := range []string{"b=2", "a=1", "a=3", "k=v=w", "empty="} {
+		if err := m.Set(arg); err != nil {
+			t.Fatalf("Set(%q): %v", arg, err)
+		}
+	}
+	want := []Pair{{"b", "2"}, {"a", "1"}, {"a", "3"}, {"k", "v=w"}, {"empty", ""}}
+	if got := m.Get(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Get() = %v, want %v", got, want)
+	}
+	if got, want := m.String(), "[b=2 a=1 a=3 k=v=w empty=]"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestMapFlagsInvalid(t *testing.T) {
+	tests := []struct {
+		arg  string
+		want string
+	}{
+		{"novalue", `missing '=' in "novalue" (expected key=value)`},
+		{"", `missing '=' in "" (expected key=value)`},
+		{"=value", `missing key before '=' in "=value"`},
+	}
+	for _, tt := range tests {
+		var m MapFlags
+		if err := m.Set(tt.arg); err == nil || err.Error() != tt.want {
+			t.Errorf("Set(%q) error = %v, want %q", tt.arg, err, tt.want)
+		}
+		if len(m) != 0 {
+			t.Errorf("Set(%q) appended %v", tt.arg, m)
+		}
+	}
+}
@@ -2,4 +2,13 @@ module github.com/mclellac/hurl
 
 go 1.24.2
 
-require github.com/spf13/pflag v1.0.6
+require (
+	github.com/BurntSushi/toml v1.5.0
+	github.com/andybalholm/brotli v1.2.5
+	github.com/spf13/pflag v1.0.6
+	golang.org/x/net v0.46.0
+	golang.org/x/term v0.36.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require golang.org/x/sys v0.37.0 // indirect
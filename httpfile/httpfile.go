@@ -0,0 +1,224 @@
+// Package httpfile reads requests from the .http and .rest files used by
+// editor REST clients such as VS Code's REST Client.
+//
+// A file holds one or more requests separated by lines starting with
+// "###". Each request is an optional method and a URL on one line
+// ("POST https://example.com/items HTTP/1.1", or just the URL for GET),
+// query lines starting with '?' or '&' that continue the URL, header lines,
+// a blank line and the body. A body of the form "< path" is read from that
+// file. Lines starting with '#' or "//" before the request line are
+// comments; "# @name NAME" names the request. Variables are defined with
+// "@name = value" and used as {{name}} in the URL, headers and body of the
+// requests after the definition; defining a name again changes it for the
+// requests that follow. {{$processEnv NAME}} is the environment variable
+// NAME.
+package httpfile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Request is one request read from a file.
+type Request struct {
+	Name    string   // From "# @name NAME" or the text after "###"; may be empty
+	Method  string   // Upper-case method; "GET" if the file gives none
+	URL     string   // URL with variables substituted
+	Headers []string // "Key: Value" lines, in file order
+	Body    []byte   // nil if the request has no body
+	Line    int      // Line number of the request line
+}
+
+// block is a request as read, before its variables are substituted.
+type block struct {
+	name    string
+	method  string
+	url     string
+	headers []string
+	body    string
+	line    int
+}
+
+var (
+	requestLineRE = regexp.MustCompile(`^(?:([A-Za-z]+)\s+)?(\S+)(?:\s+HTTP/[0-9.]+)?$`)
+	variableRE    = regexp.MustCompile(`^@([A-Za-z_][A-Za-z0-9_.-]*)\s*=\s*(.*)$`)
+	nameRE        = regexp.MustCompile(`^(?:#|//)\s*@name\s+(\S+)`)
+	referenceRE   = regexp.MustCompile(`\{\{\s*([^{}]+?)\s*\}\}`)
+)
+
+// Load reads the request called name from the file at path, or its first
+// request if name is empty. A "< path" body is read relative to the file.
+func Load(path, name string) (*Request, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	requests, err := parse(string(data), filepath.Dir(path))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(requests) == 0 {
+		return nil, fmt.Errorf("%s: no request found", path)
+	}
+	if name == "" {
+		return &requests[0], nil
+	}
+	for i := range requests {
+		if requests[i].Name == name {
+			return &requests[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%s: no request named %q", path, name)
+}
+
+// Parse reads every request in data. A "< path" body is read relative to
+// the current directory.
+func Parse(data string) ([]Request, error) {
+	return parse(data, ".")
+}
+
+func parse(data, dir string) ([]Request, error) {
+	vars := make(map[string]string)
+	var requests []Request
+	var current *block
+	// finish substitutes the variables defined so far into the current
+	// block, so later definitions do not reach back to it.
+	finish := func() error {
+		if current == nil || current.url == "" {
+			return nil // A separator with only comments or variables after it
+		}
+		req, err := current.request(vars, dir)
+		if err != nil {
+			return err
+		}
+		requests = append(requests, req)
+		return nil
+	}
+	inBody := false
+	lines := strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n")
+
+	for i, line := range lines {
+		lineNo := i + 1
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "###") {
+			if err := finish(); err != nil {
+				return nil, err
+			}
+			current = &block{name: strings.TrimSpace(strings.TrimLeft(trimmed, "#"))}
+			inBody = false
+			continue
+		}
+		if current == nil {
+			current = &block{}
+		}
+
+		switch {
+		case inBody:
+			current.body += line + "\n"
+		case current.url == "":
+			// Before the request line: comments, names and variables.
+			if trimmed == "" {
+				continue
+			}
+			if m := nameRE.FindStringSubmatch(trimmed); m != nil {
+				current.name = m[1]
+				continue
+			}
+			if strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "//") {
+				continue
+			}
+			if m := variableRE.FindStringSubmatch(trimmed); m != nil {
+				vars[m[1]] = m[2]
+				continue
+			}
+			m := requestLineRE.FindStringSubmatch(trimmed)
+			if m == nil {
+				return nil, fmt.Errorf("line %d: expected a request line such as \"GET https://example.com\"", lineNo)
+			}
+			current.method = strings.ToUpper(m[1])
+			if current.method == "" {
+				current.method = "GET"
+			}
+			current.url = m[2]
+			current.line = lineNo
+		case trimmed == "":
+			inBody = true
+		case len(current.headers) == 0 && (trimmed[0] == '?' || trimmed[0] == '&'):
+			current.url += trimmed
+		case strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "//"):
+		default:
+			if !strings.Contains(trimmed, ":") {
+				return nil, fmt.Errorf("line %d: expected a header as \"Key: Value\", got %q", lineNo, trimmed)
+			}
+			current.headers = append(current.headers, trimmed)
+		}
+	}
+	if err := finish(); err != nil {
+		return nil, err
+	}
+	return requests, nil
+}
+
+// request substitutes vars into b and reads a "< path" body, relative to
+// dir.
+func (b *block) request(vars map[string]string, dir string) (Request, error) {
+	req := Request{Name: b.name, Method: b.method, Line: b.line}
+	var err error
+	if req.URL, err = expand(b.url, vars); err != nil {
+		return req, fmt.Errorf("line %d: %w", b.line, err)
+	}
+	for _, h := range b.headers {
+		value, err := expand(h, vars)
+		if err != nil {
+			return req, fmt.Errorf("line %d: %w", b.line, err)
+		}
+		req.Headers = append(req.Headers, value)
+	}
+	body := strings.TrimRight(b.body, "\n")
+	if file, ok := strings.CutPrefix(body, "< "); ok && !strings.Contains(file, "\n") {
+		file = strings.TrimSpace(file)
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(dir, file)
+		}
+		if req.Body, err = os.ReadFile(file); err != nil {
+			return req, fmt.Errorf("line %d: %w", b.line, err)
+		}
+	} else if body != "" {
+		text, err := expand(body, vars)
+		if err != nil {
+			return req, fmt.Errorf("line %d: %w", b.line, err)
+		}
+		req.Body = []byte(text)
+	}
+	return req, nil
+}
+
+// expand substitutes the {{name}} references in s. Variable values may
+// refer to other variables; references nested deeper than the number of
+// variables are treated as a cycle.
+func expand(s string, vars map[string]string) (string, error) {
+	var err error
+	for depth := 0; referenceRE.MatchString(s); depth++ {
+		if depth > len(vars) {
+			return "", fmt.Errorf("variables refer to each other in a cycle in %q", s)
+		}
+		s = referenceRE.ReplaceAllStringFunc(s, func(ref string) string {
+			name := referenceRE.FindStringSubmatch(ref)[1]
+			if envName, ok := strings.CutPrefix(name, "$processEnv "); ok {
+				return os.Getenv(strings.TrimSpace(envName))
+			}
+			value, ok := vars[name]
+			if !ok && err == nil {
+				err = fmt.Errorf("undefined variable {{%s}}", name)
+			}
+			return value
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+	return s, nil
+}
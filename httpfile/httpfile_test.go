@@ -0,0 +1,145 @@
+package httpfile
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	t.Setenv("HURL_TEST_TOKEN", "t0ken")
+	data := `@host = https://api.example.com
+@user = alice
+
+# @name list
+GET {{host}}/users
+    ?page=2
+    &per_page={{ user }}
+Accept: application/json
+
+### create
+// A comment before the request line
+POST {{host}}/users HTTP/1.1
+Content-Type: application/json
+Authorization: Bearer {{$processEnv HURL_TEST_TOKEN}}
+
+{"name": "{{user}}"}
+
+###
+
+https://example.com/plain
+`
+	requests, err := Parse(strings.ReplaceAll(data, "\n", "\r\n"))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	want := []Request{
+		{
+			Name:    "list",
+			Method:  "GET",
+			URL:     "https://api.example.com/users?page=2&per_page=alice",
+			Headers: []string{"Accept: application/json"},
+			Line:    5,
+		},
+		{
+			Name:    "create",
+			Method:  "POST",
+			URL:     "https://api.example.com/users",
+			Headers: []string{"Content-Type: application/json", "Authorization: Bearer t0ken"},
+			Body:    []byte(`{"name": "alice"}`),
+			Line:    12,
+		},
+		{Method: "GET", URL: "https://example.com/plain", Line: 20},
+	}
+	if !reflect.DeepEqual(requests, want) {
+		t.Errorf("Parse =\n%+v\nwant\n%+v", requests, want)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{"bad request line", "GET https://example.com extra words", "line 1: expected a request line"},
+		{"bad header", "GET https://example.com\nnot a header", `line 2: expected a header as "Key: Value"`},
+		{"undefined variable", "GET {{host}}/x", "line 1: undefined variable {{host}}"},
+		{"cycle", "@a = {{b}}\n@b = {{a}}\nGET https://example.com/{{a}}", "line 3: variables refer to each other in a cycle"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.data)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Parse error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "body.json"), []byte(`{"from": "file"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "api.http")
+	data := "GET https://example.com/first\n\n### upload\nPUT https://example.com/upload\n\n< body.json\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := Load(path, "")
+	if err != nil || first.URL != "https://example.com/first" {
+		t.Errorf("Load of the first request = %+v, %v", first, err)
+	}
+	upload, err := Load(path, "upload")
+	if err != nil {
+		t.Fatalf("Load(upload): %v", err)
+	}
+	if upload.Method != "PUT" || string(upload.Body) != `{"from": "file"}` {
+		t.Errorf("Load(upload) = %s with body %q, want PUT with the contents of body.json", upload.Method, upload.Body)
+	}
+	if _, err := Load(path, "missing"); err == nil || !strings.Contains(err.Error(), `no request named "missing"`) {
+		t.Errorf("Load(missing) error = %v", err)
+	}
+
+	empty := filepath.Join(dir, "empty.http")
+	if err := os.WriteFile(empty, []byte("# nothing here\n@x = 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(empty, ""); err == nil || !strings.Contains(err.Error(), "no request found") {
+		t.Errorf("Load of a file without requests error = %v", err)
+	}
+}
+
+func TestParseVariablesApplyToLaterRequests(t *testing.T) {
+	data := `@host = https://one.example
+GET {{host}}/first
+
+###
+@host = https://two.example
+GET {{host}}/second
+
+###
+GET {{host}}/third
+`
+	requests, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	var urls []string
+	for _, req := range requests {
+		urls = append(urls, req.URL)
+	}
+	want := []string{"https://one.example/first", "https://two.example/second", "https://two.example/third"}
+	if !reflect.DeepEqual(urls, want) {
+		t.Errorf("URLs = %q, want %q", urls, want)
+	}
+
+	_, err = Parse("GET {{host}}/early\n\n###\n@host = https://late.example\nGET {{host}}/late\n")
+	if err == nil || !strings.Contains(err.Error(), "line 1: undefined variable {{host}}") {
+		t.Errorf("Parse of a variable used before its definition error = %v", err)
+	}
+}
@@ -1,37 +1,158 @@
 package main
 
 import (
-	// Use pflag instead of the standard flag package
-	flag "github.com/spf13/pflag"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"strings"
+	"syscall"
+	"time"
+
+	// Use pflag instead of the standard flag package
+	flag "github.com/spf13/pflag"
 
 	"github.com/mclellac/hurl/config"
-	"github.com/mclellac/hurl/display"
 	"github.com/mclellac/hurl/flagvar"
+	"github.com/mclellac/hurl/httpfile"
 	"github.com/mclellac/hurl/network"
+	"golang.org/x/term"
 )
 
+// exitHTTPError is the exit code used with --fail when the server responds
+// with a status of 400 or above. Transport and usage errors exit with 1.
+const exitHTTPError = 22
+
+// exitInterrupted is the exit code after SIGINT or SIGTERM stops a transfer,
+// the shell convention of 128 plus the signal number of SIGINT.
+const exitInterrupted = 130
+
 func main() {
 	// Define flags using pflag
 	var customHeaders flagvar.HeaderFlags
+	var dataArgs flagvar.DataFlags
+	var formArgs flagvar.MapFlags
+	var urlQueryArgs flagvar.MapFlags
 
 	// Use pflag's "P" variants to define both long and short flags together
-	methodPtr := flag.StringP("request", "X", "GET", "HTTP request method")
-	flag.VarP(&customHeaders, "header", "H", "Add custom request header (e.g., \"Key: Value\")")
+	methodPtr := flag.StringP("request", "X", "GET", "HTTP request method (GET, POST, PUT, DELETE, PATCH, HEAD, OPTIONS, TRACE or CONNECT)")
+	allowAnyMethodPtr := flag.Bool("allow-any-method", false, "Accept any -X method that is a valid HTTP token, not just the standard ones")
+	flag.VarP(&customHeaders, "header", "H", "Add custom request header (e.g., \"Key: Value\"), or @file to read one header per line")
+	noExpandPtr := flag.Bool("no-expand", false, "Send -H values as given instead of expanding $VAR and ${VAR} from the environment")
+	strictExpandPtr := flag.Bool("strict-expand", false, "Fail if a -H value refers to an environment variable that is not set")
+	certPtr := flag.String("cert", "", "Client certificate file (PEM) for mutual TLS; may also contain the key")
+	keyPtr := flag.String("key", "", "Private key file (PEM) for --cert")
+	pinnedPubKeyPtr := flag.String("pinnedpubkey", "", "Require the server's public key to match one of these sha256//BASE64 pins (';'-separated)")
+	showCertPtr := flag.Bool("show-cert", false, "Print the server's certificate chain")
+	certOutPtr := flag.String("cert-out", "", "Save the server's certificate as PEM to this file")
+	certExpiryWarnPtr := flag.Int("cert-expiry-warn", 30, "Warn if the server certificate expires within this many days (checked with -v, or when given)")
+	tlsMinPtr := flag.String("tls-min", "", "Minimum TLS version to allow (1.0, 1.1, 1.2 or 1.3)")
+	tlsMaxPtr := flag.String("tls-max", "", "Maximum TLS version to allow (1.0, 1.1, 1.2 or 1.3)")
+	rangePtr := flag.StringP("range", "r", "", "Request only these bytes, e.g. 0-499, 500- or -500 (comma-separate several ranges)")
+	compressedPtr := flag.Bool("compressed", false, "Request a compressed response (gzip, deflate, br) and decode it")
+	http11Ptr := flag.Bool("http1.1", false, "Use HTTP/1.1 only")
+	http2Ptr := flag.Bool("http2", false, "Use HTTP/2 when the server supports it over TLS")
 	insecurePtr := flag.BoolP("insecure", "k", false, "Allow insecure server connections")
+	unixSocketPtr := flag.String("unix-socket", "", "Connect through this Unix domain socket instead of the URL's host")
+	connectToPtr := flag.StringArray("connect-to", nil, "Connect to CONNECT_HOST:CONNECT_PORT for requests to HOST:PORT, given as HOST:PORT:CONNECT_HOST:CONNECT_PORT (repeatable)")
+	sniPtr := flag.String("sni", "", "Send this TLS server name (SNI) instead of the URL's host, and verify the certificate against it")
+	interfacePtr := flag.String("interface", "", "Bind outgoing connections to this local IP address or network interface")
+	dohURLPtr := flag.String("doh-url", "", "Resolve host names with this DNS-over-HTTPS server (e.g. https://1.1.1.1/dns-query)")
+	noDNSCachePtr := flag.Bool("no-dns-cache", false, "Resolve host names for every connection instead of caching the answers")
+	proxyPtr := flag.StringP("proxy", "x", "", "Use the given proxy (http://, https:// or socks5://, with optional user:password@)")
 	locationPtr := flag.BoolP("location", "L", false, "Follow redirects (HTTP 3xx)")
+	locationTrustedPtr := flag.Bool("location-trusted", false, "Like -L, but also send credentials to other hosts redirects lead to")
+	maxRedirsPtr := flag.Int("max-redirs", 10, "Maximum number of redirects to follow with -L (-1 for unlimited)")
 	headPtr := flag.BoolP("head", "I", false, "Perform HTTP HEAD request (overrides -X)")
-	verbosePtr := flag.BoolP("verbose", "v", false, "Make the operation more talkative")
+	optionsPtr := flag.Bool("options", false, "Send an OPTIONS request (OPTIONS * for a URL without a path) and list the allowed methods and CORS headers")
+	headersOnlyPtr := flag.Bool("show-headers-only", false, "Send the request with its usual method (unlike -I, which sends HEAD), print the status line and headers and discard the body")
+	includePtr := flag.BoolP("include", "i", false, "Include the response status line and headers in the output")
+	failPtr := flag.BoolP("fail", "f", false, "Fail with exit code 22 and no body output on HTTP errors (status >= 400)")
+	silentPtr := flag.BoolP("silent", "s", false, "Silent mode: don't print errors or warnings (-v still wins)")
+	showErrorPtr := flag.BoolP("show-error", "S", false, "With -s, still print error messages")
+	outputsPtr := flag.StringArrayP("output", "o", nil, "Write the response body to this file instead of stdout (repeat to pair with each URL)")
+	remoteNamePtr := flag.BoolP("remote-name", "O", false, "Write each body to a file named after the last part of the URL path (or the Content-Disposition file name)")
+	remoteHeaderNamePtr := flag.BoolP("remote-header-name", "J", false, "With -O, prefer the file name from the Content-Disposition header over the URL")
+	outputDirPtr := flag.String("output-dir", "", "Directory to write -o and -O files in")
+	maxFilesizePtr := flag.String("max-filesize", "", "Abort if the response body is larger than this many bytes (suffixes k, M and G allowed)")
+	rateLimitPtr := flag.String("rate-limit", "", "Read the response body at most this many bytes per second (suffixes k, M and G allowed, e.g. 100k)")
+	etagSavePtr := flag.String("etag-save", "", "Save the response ETag to this file")
+	etagComparePtr := flag.String("etag-compare", "", "Send the ETag stored in this file as If-None-Match (a missing file sends none)")
+	timeCondPtr := flag.StringP("time-cond", "z", "", "Send If-Modified-Since with this date or the modification time of this file (a leading '-' sends If-Unmodified-Since)")
+	remoteTimePtr := flag.BoolP("remote-time", "R", false, "Set the -o file's modification time to the response's Last-Modified date")
+	continueAtPtr := flag.StringP("continue-at", "C", "", "Resume the transfer at this byte offset, appending to the -o file (\"-\" uses the size of the -o file)")
+	verbosePtr := flag.BoolP("verbose", "v", false, "Make the operation more talkative (same as --verbose-level 3)")
+	verboseLevelPtr := flag.Int("verbose-level", 0, "Verbosity: 1 = request and status lines, 2 = +headers, 3 = +connection trace (-v), 4 = +TLS details")
+	flag.VarP(dataArgs.Flag(flagvar.DataASCII), "data", "d", "HTTP POST data (use @file to read from a file, with newlines removed); repeated values are joined with '&'")
+	flag.Var(dataArgs.Flag(flagvar.DataBinary), "data-binary", "HTTP POST data sent exactly as given; @file is read without stripping newlines")
+	flag.Var(dataArgs.Flag(flagvar.DataURLEncode), "data-urlencode", "HTTP POST data to URL-encode: content, =content, name=content, @file or name@file")
+	dataTemplatePtr := flag.String("data-template", "", "HTTP POST data rendered as a Go text/template with .Env, env, uuid and now (use @file to read from a file)")
+	flag.VarP(&formArgs, "form", "F", "Add a multipart/form-data field: name=value or name=@file, with optional ;type= and ;filename=")
+	flag.Var(&urlQueryArgs, "url-query", "Append a URL-encoded name=value query parameter to the URL (repeatable)")
+	chunkedPtr := flag.Bool("chunked", false, "Send the request body with chunked transfer encoding, without a Content-Length")
+	getPtr := flag.BoolP("get", "G", false, "Send the -d data as URL query parameters in a GET request")
+	userAgentPtr := flag.StringP("user-agent", "A", "", "User-Agent to send (an explicit \"\" sends none)")
+	refererPtr := flag.StringP("referer", "e", "", "Referer URL to send; append \";auto\" (or use \";auto\" alone) to set it automatically on redirects")
+	cookiePtr := flag.StringP("cookie", "b", "", "Send cookies from a \"name=value; name2=value2\" string or load them from a Netscape cookie file")
+	cookieJarPtr := flag.StringP("cookie-jar", "c", "", "Write all cookies to this file in Netscape format after the request")
+	userPtr := flag.StringP("user", "u", "", "Server user and password as \"user:password\" (prompts for the password if omitted)")
+	bearerPtr := flag.String("bearer", "", "Send \"Authorization: Bearer <token>\" with the request")
+	netrcPtr := flag.BoolP("netrc", "n", false, "Read credentials for the host from ~/.netrc")
+	netrcFilePtr := flag.String("netrc-file", "", "Read credentials for the host from this .netrc file (implies --netrc)")
+	bearerCommandPtr := flag.String("bearer-command", "", "Run this shell command and send its output as the bearer token")
+	jsonPtr := flag.String("json", "", "HTTP POST JSON data (use @file to read from a file)")
+	graphqlPtr := flag.String("graphql", "", "POST this GraphQL query as a JSON {\"query\": ...} body (use @file to read from a file)")
+	graphqlVarsPtr := flag.String("graphql-vars", "", "JSON object of variables sent with the --graphql query (use @file to read from a file)")
 
 	// Flags without short versions remain the same
 	akamaiPragmaPtr := flag.Bool("akamai-pragma", false, "Send Akamai Pragma debug headers")
+	retryPtr := flag.Int("retry", 0, "Retry transient failures (connection errors, timeouts, retryable statuses) this many times")
+	retryDelayPtr := flag.Duration("retry-delay", time.Second, "Base delay between retries, doubled on each attempt (Retry-After is honored)")
+	retryOnStatusPtr := flag.IntSlice("retry-on-status", nil, "Comma-separated response statuses to retry (default 429 and 5xx)")
+	repeatPtr := flag.Int("repeat", 1, "Send each request this many times and print a table of response times with min/avg/percentiles/max")
+	metricsOutPtr := flag.String("metrics-out", "", "With --repeat, write each request's timings to this file as CSV (or JSON lines for a .json file)")
+	ssePtr := flag.Bool("sse", false, "Read the response as a Server-Sent Events stream, printing each event as it arrives and reconnecting with Last-Event-ID until --max-time")
+	wsProbePtr := flag.Bool("ws-probe", false, "Send a WebSocket handshake and report whether the server accepts the upgrade, then close the connection")
+	repeatDelayPtr := flag.Duration("repeat-delay", 0, "Pause between the requests of --repeat, e.g. 100ms")
+	globoffPtr := flag.BoolP("globoff", "g", false, "Turn off URL globbing, so [] and {} in URLs are sent as they are")
+	parallelPtr := flag.BoolP("parallel", "Z", false, "Fetch the URLs concurrently; output is still printed in URL order")
+	parallelMaxPtr := flag.Int("parallel-max", 50, "Maximum number of concurrent transfers with --parallel")
+	removeOnErrorPtr := flag.Bool("remove-on-error", false, "Delete the -o file if the transfer fails or is interrupted")
+	traceFilePtr := flag.String("trace-file", "", "Write the verbose diagnostics (-v, --verbose-level) to this file instead of stderr")
+	traceTimePtr := flag.String("trace-time", "", "Prefix each verbose diagnostic line with the time: relative (seconds since the request started, the default) or absolute")
+	flag.Lookup("trace-time").NoOptDefVal = network.TraceTimeRelative
+	stderrPtr := flag.String("stderr", "", "Redirect everything hurl prints on stderr to this file (\"-\" for stdout)")
+	traceASCIIPtr := flag.String("trace-ascii", "", "Write a timestamped plain-text dump of the request and response, bodies included, to this file (\"-\" for stderr)")
+	timingsPtr := flag.Bool("timings", false, "Print a breakdown of DNS, connect, TLS, first byte and total times to stderr")
+	writeOutPtr := flag.StringP("write-out", "w", "", "Print the given format after the transfer, e.g. '%{http_code} %{time_total}\\n'")
+	colorPtr := flag.String("color", "auto", "Colorize output: auto (only on terminals), always or never")
+	jsonOutputPtr := flag.Bool("json-output", false, "Print the request, response headers and body, and timings as one JSON object")
+	prettyPtr := flag.Bool("pretty", false, "Pretty-print and colorize JSON response bodies")
+	noKeepAlivePtr := flag.Bool("no-keepalive", false, "Open a new connection for every request and send no TCP keep-alive probes")
+	keepAliveTimePtr := flag.Duration("keepalive-time", 30*time.Second, "Interval between TCP keep-alive probes on idle connections")
+	noHappyEyeballsPtr := flag.Bool("no-happy-eyeballs", false, "Try a dual-stack host's IPv6 and IPv4 addresses one after another instead of racing them")
+	happyEyeballsDelayPtr := flag.Duration("happy-eyeballs-delay", 300*time.Millisecond, "Head start of the first address family before the other one is tried in parallel")
+	expect100TimeoutPtr := flag.Duration("expect100-timeout", time.Second, "How long to wait for 100 Continue after sending \"Expect: 100-continue\" (0 sends the body right away)")
+	connectTimeoutPtr := flag.Duration("connect-timeout", 0, "Maximum time allowed for establishing the connection (0 uses the default of 30s)")
+	configPathPtr := flag.String("config-file", "", "Load settings from this JSON, YAML or TOML file instead of the default location (overrides $HURL_CONFIG)")
+	strictConfigPtr := flag.Bool("strict-config", false, "Treat unknown fields and invalid values in the config file as errors")
+	writeDefaultConfigPtr := flag.Bool("write-default-config", false, "Write the default settings to the config file as a template and exit")
+	forcePtr := flag.Bool("force", false, "With --write-default-config, overwrite an existing config file")
+	configFilePtr := flag.StringP("config", "K", "", "Read options and URLs from this curl-style config file (\"-\" for stdin); command-line flags take precedence")
+	requestFilePtr := flag.String("file", "", "Send the first request in this .http file (method, URL, headers and body); other flags still apply")
+	requestNamePtr := flag.String("request-name", "", "With --file, send the request named by \"# @name NAME\" or \"### NAME\" instead of the first one")
+	maxTimePtr := flag.Duration("max-time", 30*time.Second, "Maximum time allowed for the whole request, e.g. 5s or 500ms (0 means no timeout)")
 
 	// pflag handles --help/-h automatically and correctly formats Usage
 	flag.Usage = func() {
 		// Custom usage message format
-		fmt.Fprintf(os.Stderr, "Usage: %s [flags] <URL>\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Usage: %s [flags] <URL>...\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "Example: %s -I https://www.example.com\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "Example: %s -L http://httpbin.org/redirect/1\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "\nFlags:\n")
@@ -40,64 +161,817 @@ func main() {
 
 	flag.Parse()
 
-	if flag.NArg() != 1 {
+	urls := flag.Args()
+	if *configFilePtr != "" {
+		// Errors in the file are colored per the command-line --color.
+		stderrConfig.Color = colorEnabled(strings.ToLower(*colorPtr), os.Stderr)
+		fileURLs, err := loadConfigFile(*configFilePtr, flag.CommandLine)
+		if err != nil {
+			fatalf(1, "Error: --config: %v", err)
+		}
+		urls = append(urls, fileURLs...)
+	}
+
+	// --stderr redirects every message, so it is applied before any are
+	// printed (apart from problems with the -K file itself).
+	switch *stderrPtr {
+	case "":
+	case "-":
+		os.Stderr = os.Stdout
+	default:
+		f, err := os.Create(*stderrPtr)
+		if err != nil {
+			fatalf(1, "Error: --stderr: %v", err)
+		}
+		os.Stderr = f
+	}
+
+	colorMode := strings.ToLower(*colorPtr)
+	stderrConfig.Color = colorEnabled(colorMode, os.Stderr)
+	if colorMode != "auto" && colorMode != "always" && colorMode != "never" {
+		fatalf(1, "Error: invalid --color value %q (use auto, always or never)", *colorPtr)
+	}
+
+	// The settings file comes from --config-file, then $HURL_CONFIG, then the
+	// default location. Both options may also be given in the -K file.
+	configPath := *configPathPtr
+	if !flag.CommandLine.Changed("config-file") {
+		configPath = os.Getenv("HURL_CONFIG")
+	}
+
+	if *writeDefaultConfigPtr {
+		path, err := config.WriteDefaultConfig(configPath, *forcePtr)
+		if err != nil {
+			fatalf(1, "Error: %v", err)
+		}
+		fmt.Println(path)
+		os.Exit(0)
+	}
+
+	expanded, err := expandURLs(urls, *globoffPtr)
+	if err != nil {
+		fatalf(1, "Error: %v", err)
+	}
+	urls = expanded
+	// A --file request supplies the URL; its headers and body are merged
+	// with the command-line ones below.
+	var fileRequest *httpfile.Request
+	if *requestNamePtr != "" && *requestFilePtr == "" {
+		fatalf(1, "Error: --request-name needs --file")
+	}
+	if *requestFilePtr != "" {
+		if len(urls) > 0 {
+			fatalf(1, "Error: --file cannot be combined with URL arguments")
+		}
+		req, err := httpfile.Load(*requestFilePtr, *requestNamePtr)
+		if err != nil {
+			fatalf(1, "Error: --file: %v", err)
+		}
+		fileRequest = req
+		urls = append(urls, req.URL)
+	}
+	if len(urls) < 1 {
 		flag.Usage() // Print the usage message on error
 		os.Exit(1)
 	}
-	url := flag.Arg(0)
 
-	method := strings.ToUpper(*methodPtr)
-	if *headPtr {
-		method = "HEAD"
+	// -v is --verbose-level 3; an explicit level wins.
+	verbosity := 0
+	if *verbosePtr {
+		verbosity = network.VerboseConnection
+	}
+	if flag.CommandLine.Changed("verbose-level") {
+		if *verboseLevelPtr < 0 || *verboseLevelPtr > network.VerboseTLS {
+			fatalf(1, "Error: --verbose-level must be between 0 and %d", network.VerboseTLS)
+		}
+		verbosity = *verboseLevelPtr
+	}
+
+	traceTime := ""
+	if flag.CommandLine.Changed("trace-time") {
+		var err error
+		if traceTime, err = network.ParseTraceTime(*traceTimePtr); err != nil {
+			fatalf(1, "Error: %v", err)
+		}
+	}
+
+	// Verbose output wins over silent mode.
+	silent := *silentPtr && verbosity == 0
+	if silent && !*showErrorPtr {
+		showErrors = false
+	}
+
+	followRedirects := *locationPtr || *locationTrustedPtr
+
+	if len(dataArgs) > 0 && flag.CommandLine.Changed("json") {
+		fatalf(1, "Error: --data and --json cannot be used together")
+	}
+	if flag.CommandLine.Changed("data-template") && (len(dataArgs) > 0 || flag.CommandLine.Changed("json") || len(formArgs) > 0) {
+		fatalf(1, "Error: --data-template cannot be combined with --data, --json or --form")
+	}
+	if len(formArgs) > 0 && (len(dataArgs) > 0 || flag.CommandLine.Changed("json")) {
+		fatalf(1, "Error: --form cannot be combined with --data or --json")
+	}
+	if flag.CommandLine.Changed("graphql") && (len(dataArgs) > 0 || flag.CommandLine.Changed("json") || len(formArgs) > 0 || flag.CommandLine.Changed("data-template")) {
+		fatalf(1, "Error: --graphql cannot be combined with --data, --json, --form or --data-template")
+	}
+	if flag.CommandLine.Changed("graphql-vars") && !flag.CommandLine.Changed("graphql") {
+		fatalf(1, "Error: --graphql-vars needs --graphql")
+	}
+
+	// newBody returns a fresh request body and its Content-Type for each URL;
+	// it stays nil when no data is sent.
+	var newBody func() (io.Reader, string, error)
+	accept := ""
+	if len(dataArgs) > 0 {
+		// Standard input can only be streamed to a single request.
+		replay := len(urls) > 1 || *repeatPtr > 1 || *ssePtr
+		makeBody, err := dataBody(dataArgs, replay)
+		if err != nil {
+			fatalf(1, "Error reading data: %v", err)
+		}
+		newBody = func() (io.Reader, string, error) {
+			return makeBody(), "application/x-www-form-urlencoded", nil
+		}
+	}
+	if flag.CommandLine.Changed("json") {
+		data, err := readDataArg(*jsonPtr, true)
+		if err != nil {
+			fatalf(1, "Error reading JSON data: %v", err)
+		}
+		if !json.Valid(data) {
+			fatalf(1, "Error: --json data is not valid JSON")
+		}
+		newBody = func() (io.Reader, string, error) {
+			return bytes.NewReader(data), "application/json", nil
+		}
+		accept = "application/json"
+	}
+	if flag.CommandLine.Changed("graphql") {
+		query, err := readDataArg(*graphqlPtr, true)
+		if err != nil {
+			fatalf(1, "Error reading GraphQL query: %v", err)
+		}
+		var variables []byte
+		if *graphqlVarsPtr != "" {
+			if variables, err = readDataArg(*graphqlVarsPtr, true); err != nil {
+				fatalf(1, "Error reading GraphQL variables: %v", err)
+			}
+		}
+		data, err := graphqlBody(string(query), variables)
+		if err != nil {
+			fatalf(1, "Error: %v", err)
+		}
+		newBody = func() (io.Reader, string, error) {
+			return bytes.NewReader(data), "application/json", nil
+		}
+		accept = "application/json"
+	}
+	if flag.CommandLine.Changed("data-template") {
+		text, err := readDataArg(*dataTemplatePtr, true)
+		if err != nil {
+			fatalf(1, "Error reading data template: %v", err)
+		}
+		name := "data-template"
+		if file, ok := strings.CutPrefix(*dataTemplatePtr, "@"); ok && file != "-" {
+			name = filepath.Base(file)
+		}
+		render, err := templateBody(name, text)
+		if err != nil {
+			fatalf(1, "Error: --data-template: %v", err)
+		}
+		newBody = func() (io.Reader, string, error) {
+			data, err := render()
+			if err != nil {
+				return nil, "", fmt.Errorf("--data-template: %w", err)
+			}
+			return bytes.NewReader(data), "application/x-www-form-urlencoded", nil
+		}
+	}
+	if len(formArgs) > 0 {
+		if *getPtr {
+			fatalf(1, "Error: --get cannot be used with --form")
+		}
+		fields := make([]network.FormField, len(formArgs))
+		for i, arg := range formArgs.Get() {
+			field, err := network.ParseFormField(arg.Key, arg.Value)
+			if err != nil {
+				fatalf(1, "Error: %v", err)
+			}
+			fields[i] = field
+		}
+		newBody = func() (io.Reader, string, error) {
+			body, contentType, err := network.NewMultipartBody(fields)
+			if err != nil {
+				return nil, "", fmt.Errorf("error reading form data: %w", err)
+			}
+			return body, contentType, nil
+		}
+	}
+	// -d, --json, -F, --data-template, --graphql and -X replace the file's
+	// body and method.
+	requestMethod, explicitMethod := *methodPtr, flag.CommandLine.Changed("request")
+	if fileRequest != nil {
+		if body := fileRequest.Body; body != nil && newBody == nil {
+			newBody = func() (io.Reader, string, error) {
+				return bytes.NewReader(body), "", nil // Content-Type comes from the file's headers
+			}
+		}
+		if !explicitMethod && !*headPtr && !*optionsPtr {
+			requestMethod, explicitMethod = fileRequest.Method, true
+		}
+	}
+	if *getPtr && flag.CommandLine.Changed("json") {
+		fatalf(1, "Error: --get cannot be used with --json")
+	}
+	if *getPtr && flag.CommandLine.Changed("graphql") {
+		fatalf(1, "Error: --get cannot be used with --graphql")
+	}
+	method, err := resolveMethod(methodFlags{
+		Method:   requestMethod,
+		Explicit: explicitMethod,
+		Head:     *headPtr,
+		Options:  *optionsPtr,
+		Get:      *getPtr,
+		HasBody:  newBody != nil,
+		AllowAny: *allowAnyMethodPtr,
+	})
+	if err != nil {
+		fatalf(1, "Error: %v", err)
+	}
+
+	if flag.CommandLine.Changed("user") && flag.CommandLine.Changed("bearer") {
+		fatalf(1, "Error: --user and --bearer both set the Authorization header and cannot be used together")
 	}
-	followRedirects := *locationPtr
 
-	err := config.EnsureConfigDir()
+	bearerToken := *bearerPtr
+	if *bearerCommandPtr != "" {
+		if flag.CommandLine.Changed("user") || flag.CommandLine.Changed("bearer") {
+			fatalf(1, "Error: --bearer-command cannot be combined with --user or --bearer")
+		}
+		bearerToken, err = runBearerCommand(*bearerCommandPtr)
+		if err != nil {
+			fatalf(1, "Error: --bearer-command: %v", err)
+		}
+	}
+
+	var authUser, authPass string
+	if flag.CommandLine.Changed("user") {
+		var err error
+		authUser, authPass, err = parseUserArg(*userPtr)
+		if err != nil {
+			fatalf(1, "Error: %v", err)
+		}
+	}
+
+	// -u and --bearer take precedence over credentials from .netrc.
+	var netrc *network.Netrc
+	if *netrcPtr || *netrcFilePtr != "" {
+		netrc, err = loadNetrc(*netrcFilePtr, func(format string, args ...any) {
+			if showErrors {
+				fmt.Fprintf(os.Stderr, "%s%s%s\n", stderrConfig.GetAnsiCode("yellow"), fmt.Sprintf(format, args...), stderrConfig.ResetCode())
+			}
+		})
+		if err != nil {
+			fatalf(1, "Error: --netrc: %v", err)
+		}
+	}
+
+	if *rangePtr != "" {
+		if err := network.ValidateRange(*rangePtr); err != nil {
+			fatalf(1, "Error: --range: %v", err)
+		}
+	}
+
+	var maxFilesize int64
+	if *maxFilesizePtr != "" {
+		maxFilesize, err = network.ParseSize(*maxFilesizePtr)
+		if err != nil {
+			fatalf(1, "Error: --max-filesize: %v", err)
+		}
+	}
+
+	var rateLimit int64
+	if *rateLimitPtr != "" {
+		rateLimit, err = network.ParseSize(strings.TrimSuffix(*rateLimitPtr, "/s"))
+		if err == nil && rateLimit == 0 {
+			err = fmt.Errorf("the rate must be more than 0")
+		}
+		if err != nil {
+			fatalf(1, "Error: --rate-limit: %v", err)
+		}
+	}
+
+	headers, undefined, err := expandHeaders(customHeaders.Get(), *noExpandPtr, *strictExpandPtr)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: Could not ensure config directory: %v\n", err)
+		fatalf(1, "Error: %v", err)
+	}
+	if len(undefined) > 0 && verbosity > 0 {
+		fmt.Fprintf(os.Stderr, "%sWarning: -H refers to unset environment variable %s; expanded to nothing%s\n", stderrConfig.GetAnsiCode("yellow"), strings.Join(undefined, ", "), stderrConfig.ResetCode())
+	}
+	if fileRequest != nil {
+		headers = mergeFileHeaders(fileRequest.Headers, headers)
 	}
-	cfg, err := config.LoadConfig()
+
+	conditional, err := conditionalHeaders(*etagComparePtr, *timeCondPtr, func(format string, args ...any) {
+		if showErrors {
+			fmt.Fprintf(os.Stderr, "%s%s%s\n", stderrConfig.GetAnsiCode("yellow"), fmt.Sprintf(format, args...), stderrConfig.ResetCode())
+		}
+	})
 	if err != nil {
+		fatalf(1, "Error: %v", err)
+	}
+
+	if flag.CommandLine.Changed("continue-at") && *rangePtr != "" {
+		fatalf(1, "Error: --continue-at and --range cannot be used together")
+	}
+
+	if *remoteHeaderNamePtr && !*remoteNamePtr {
+		fatalf(1, "Error: --remote-header-name requires --remote-name")
+	}
+
+	if *repeatPtr < 1 {
+		fatalf(1, "Error: --repeat must be at least 1")
+	}
+	// --repeat prints its own table and discards the bodies.
+	if *repeatPtr > 1 && (len(*outputsPtr) > 0 || *remoteNamePtr || *writeOutPtr != "" || *jsonOutputPtr) {
+		fatalf(1, "Error: --repeat cannot be combined with --output, --remote-name, --write-out or --json-output")
+	}
+
+	if *ssePtr && (*repeatPtr > 1 || len(*outputsPtr) > 0 || *remoteNamePtr || *jsonOutputPtr || *headPtr || *optionsPtr) {
+		fatalf(1, "Error: --sse cannot be combined with --repeat, --output, --remote-name, --json-output, --head or --options")
+	}
+	if *wsProbePtr && (*ssePtr || *repeatPtr > 1 || len(*outputsPtr) > 0 || *remoteNamePtr || *jsonOutputPtr || *headPtr || *optionsPtr || newBody != nil) {
+		fatalf(1, "Error: --ws-probe cannot be combined with --sse, --repeat, --output, --remote-name, --json-output, --head, --options or request data")
+	}
+	if *metricsOutPtr != "" && *repeatPtr == 1 {
+		fatalf(1, "Error: --metrics-out requires --repeat")
+	}
+
+	// --json-output owns stdout: the document replaces the body, headers and
+	// write-out, and is never written to a file.
+	if *jsonOutputPtr {
+		if len(*outputsPtr) > 0 || *remoteNamePtr {
+			fatalf(1, "Error: --json-output writes to stdout and cannot be combined with --output or --remote-name")
+		}
+		if *writeOutPtr != "" {
+			fatalf(1, "Error: --json-output and --write-out cannot be used together")
+		}
+		if *headersOnlyPtr {
+			fatalf(1, "Error: --json-output and --show-headers-only cannot be used together")
+		}
+	}
+
+	httpVersion := ""
+	if *http11Ptr && *http2Ptr {
+		fatalf(1, "Error: --http1.1 and --http2 cannot be used together")
+	} else if *wsProbePtr && *http2Ptr {
+		fatalf(1, "Error: --ws-probe needs HTTP/1.1 and cannot be used with --http2")
+	} else if *http11Ptr || *wsProbePtr {
+		httpVersion = network.HTTPVersion11
+	} else if *http2Ptr {
+		httpVersion = network.HTTPVersion2
+	}
+
+	if *keyPtr != "" && *certPtr == "" {
+		fatalf(1, "Error: --key requires --cert")
+	}
+
+	var connectTo []network.ConnectTo
+	for _, value := range *connectToPtr {
+		rule, err := network.ParseConnectTo(value)
+		if err != nil {
+			fatalf(1, "Error: %v", err)
+		}
+		connectTo = append(connectTo, rule)
+	}
+
+	var pinnedPubKeys []string
+	if *pinnedPubKeyPtr != "" {
+		pinnedPubKeys, err = network.ParsePinnedPubKey(*pinnedPubKeyPtr)
+		if err != nil {
+			fatalf(1, "Error: --pinnedpubkey: %v", err)
+		}
+	}
+
+	tlsMin, err := network.ParseTLSVersion(*tlsMinPtr)
+	if err != nil {
+		fatalf(1, "Error: --tls-min: %v", err)
+	}
+	tlsMax, err := network.ParseTLSVersion(*tlsMaxPtr)
+	if err != nil {
+		fatalf(1, "Error: --tls-max: %v", err)
+	}
+	if tlsMin != 0 && tlsMax != 0 && tlsMin > tlsMax {
+		fatalf(1, "Error: --tls-min %s is higher than --tls-max %s", *tlsMinPtr, *tlsMaxPtr)
+	}
+
+	referer, autoReferer := strings.CutSuffix(*refererPtr, ";auto")
+
+	// Like curl, a -b value containing '=' is a literal cookie string, otherwise a file name.
+	var cookieHeader, cookieFile string
+	if strings.Contains(*cookiePtr, "=") {
+		cookieHeader = *cookiePtr
+	} else {
+		cookieFile = *cookiePtr
+	}
+
+	if configPath == "" {
+		created, err := config.EnsureConfigDir()
+		if err != nil {
+			if showErrors {
+				fmt.Fprintf(os.Stderr, "Warning: Could not ensure config directory: %v\n", err)
+			}
+		} else if created != "" && verbosity > 0 {
+			fmt.Fprintf(os.Stderr, "Info: Created config directory: %s\n", created)
+		}
+	}
+	// LoadConfig falls back to defaults for anything it cannot use; only a
+	// missing --config-file, or any problem with --strict-config, is fatal.
+	cfg, err := config.LoadConfig(configPath, *strictConfigPtr)
+	var fileErr *config.FileError
+	notFound := errors.Is(err, config.ErrConfigNotFound)
+	switch {
+	case err == nil:
+	case notFound && configPath != "":
 		fmt.Fprintf(os.Stderr, "Error loading configuration: %v. Exiting.\n", err)
 		os.Exit(1)
+	case *strictConfigPtr && errors.As(err, &fileErr):
+		red, reset := config.ColorRed, config.ColorReset
+		if !stderrConfig.Color {
+			red, reset = "", ""
+		}
+		fmt.Fprintf(os.Stderr, "%sError: invalid %v%s\n", red, fileErr, reset)
+		os.Exit(1)
+	case *strictConfigPtr && !notFound:
+		fmt.Fprintf(os.Stderr, "Error loading configuration: %v. Exiting.\n", err)
+		os.Exit(1)
+	case showErrors:
+		for _, warning := range configWarnings(err) {
+			fmt.Fprintf(os.Stderr, "Warning: %s.\n", warning)
+		}
+	}
+
+	// Each stream is colored only if --color allows it for that stream.
+	outCfg := cfg
+	outCfg.Color = colorEnabled(colorMode, os.Stdout)
+	errCfg := cfg
+	errCfg.Color = stderrConfig.Color
+	stderrConfig = errCfg
+
+	// A zero --expect100-timeout means not waiting at all.
+	expect100Timeout := *expect100TimeoutPtr
+	if expect100Timeout == 0 {
+		expect100Timeout = -1
+	}
+
+	// --no-happy-eyeballs dials the addresses one after another.
+	fallbackDelay := *happyEyeballsDelayPtr
+	if *noHappyEyeballsPtr {
+		fallbackDelay = -1
+	}
+
+	var urlQuery []string
+	for _, p := range urlQueryArgs.Get() {
+		urlQuery = append(urlQuery, p.Key+"="+p.Value)
 	}
 
 	reqOptions := network.RequestOptions{
 		Method:          method,
-		URL:             url,
-		CustomHeaders:   customHeaders.Get(),
+		CustomHeaders:   headers,
+		DefaultHeaders:  append(conditional, cfg.DefaultHeaders...),
+		DataAsQuery:     *getPtr,
+		URLQuery:        urlQuery,
+		Chunked:         *chunkedPtr,
+		Accept:          accept,
+		BasicAuthUser:   authUser,
+		BasicAuthPass:   authPass,
+		BearerToken:     bearerToken,
+		Netrc:           netrc,
+		UserAgent:       *userAgentPtr,
+		OmitUserAgent:   flag.CommandLine.Changed("user-agent") && *userAgentPtr == "",
+		Referer:         referer,
+		AutoReferer:     autoReferer,
+		Cookie:          cookieHeader,
+		CookieFile:      cookieFile,
+		CookieJarFile:   *cookieJarPtr,
+		UnixSocket:      *unixSocketPtr,
+		Interface:       *interfacePtr,
+		ConnectTo:       connectTo,
+		SNI:             *sniPtr,
+		Proxy:           *proxyPtr,
+		DoHURL:          *dohURLPtr,
+		NoDNSCache:      *noDNSCachePtr,
+		ClientCertFile:  *certPtr,
+		ClientKeyFile:   *keyPtr,
+		PinnedPubKeys:   pinnedPubKeys,
+		ShowCert:        *showCertPtr,
+		TLSMinVersion:   tlsMin,
+		TLSMaxVersion:   tlsMax,
 		InsecureSkipTLS: *insecurePtr,
+		HTTPVersion:     httpVersion,
+		Compressed:      *compressedPtr,
+		Range:           *rangePtr,
+		MaxFileSize:     maxFilesize,
+		RateLimit:       rateLimit,
 		FollowRedirects: followRedirects,
+		LocationTrusted: *locationTrustedPtr,
+		MaxRedirects:    *maxRedirsPtr,
 		AddAkamaiPragma: *akamaiPragmaPtr,
-		Verbose:         *verbosePtr,
-		Config:          cfg,
+		Verbose:         verbosity,
+		TraceTime:       traceTime,
+		Timeout:         *maxTimePtr,
+		ConnectTimeout:  *connectTimeoutPtr,
+		ExpectTimeout:   expect100Timeout,
+		NoKeepAlive:     *noKeepAlivePtr,
+		KeepAliveTime:   *keepAliveTimePtr,
+		FallbackDelay:   fallbackDelay,
+		Retries:         *retryPtr,
+		RetryDelay:      *retryDelayPtr,
+		RetryOnStatus:   *retryOnStatusPtr,
+		Config:          errCfg,
 	}
 
-	resp, err := network.Fetch(reqOptions)
+	var traceFile *os.File
+	switch *traceASCIIPtr {
+	case "":
+	case "-":
+		reqOptions.Trace = os.Stderr
+	default:
+		traceFile, err = os.Create(*traceASCIIPtr)
+		if err != nil {
+			fatalf(1, "Error creating trace file: %v", err)
+		}
+		reqOptions.Trace = traceFile
+	}
 
-	if resp != nil {
-		defer resp.Body.Close()
+	var diagFile *os.File
+	if *traceFilePtr != "" {
+		diagFile, err = os.Create(*traceFilePtr)
+		if err != nil {
+			fatalf(1, "Error creating trace file: %v", err)
+		}
+		reqOptions.Diagnostics = diagFile
+		reqOptions.Config.Color = colorEnabled(colorMode, diagFile)
 	}
 
-	// Check error from Fetch *after* attempting Close() via defer
+	// One transport serves every URL so connections are reused.
+	reqOptions.Transport, err = network.NewTransport(reqOptions)
 	if err != nil {
-		if !reqOptions.Verbose {
-			fmt.Fprintf(os.Stderr, "%sError executing request: %v%s\n", config.ColorRed, err, config.ColorReset)
+		fatalf(1, "Error: %v", err)
+	}
+
+	parallel := *parallelPtr && len(urls) > 1
+	if parallel {
+		if len(*outputsPtr) > 0 && len(*outputsPtr) != len(urls) {
+			fatalf(1, "Error: with --parallel, give one -o per URL or none")
+		}
+		if *parallelMaxPtr < 1 {
+			fatalf(1, "Error: --parallel-max must be at least 1")
 		}
-		os.Exit(1)
 	}
 
-	if !reqOptions.Verbose {
-		fmt.Printf("%s%s %s%s\n",
-			config.GetAnsiCode(cfg.HeaderValueColor),
-			resp.Proto,
-			resp.Status,
-			config.ColorReset)
+	// Certificate expiry is checked in verbose mode, or whenever asked for.
+	certCheck := flag.CommandLine.Changed("cert-expiry-warn") || verbosity >= network.VerboseConnection
+	if certCheck && *certExpiryWarnPtr < 0 {
+		fatalf(1, "Error: --cert-expiry-warn must not be negative")
+	}
+
+	outOptions := outputOptions{
+		Fail: *failPtr,
+		// The status line and headers are part of the output with -i
+		// (implied by -I and --show-headers-only), unless verbose mode already shows them on stderr.
+		Include:     (*includePtr || *headPtr || *headersOnlyPtr) && (verbosity < network.VerboseHeaders || *traceFilePtr != ""),
+		HeadersOnly: *headersOnlyPtr,
+		Options:     *optionsPtr,
+		// Concurrent progress bars would overwrite each other.
+		Progress:  !silent && !parallel,
+		Pretty:    *prettyPtr,
+		JSON:      *jsonOutputPtr,
+		Timings:   *timingsPtr || verbosity >= network.VerboseConnection,
+		WriteOut:  *writeOutPtr,
+		ColorMode: colorMode,
+		Out:       outCfg,
+		Err:       errCfg,
+		Stdout:    os.Stdout,
+		Stderr:    os.Stderr,
+
+		Diagnostics: reqOptions.Diagnostics,
+		Trace:       reqOptions.Trace,
+
+		RemoveOnError: *removeOnErrorPtr,
+		RemoteName:    *remoteNamePtr,
+		HeaderName:    *remoteHeaderNamePtr,
+		OutputDir:     *outputDirPtr,
+		ETagSave:      *etagSavePtr,
+		RemoteTime:    *remoteTimePtr,
+		CertOut:       *certOutPtr,
+		CertCheck:     certCheck,
+		CertWarnDays:  *certExpiryWarnPtr,
+		Quiet:         silent,
+	}
+
+	// Ctrl-C or SIGTERM cancels the transfers in flight; output written so
+	// far is kept (see --remove-on-error) and hurl exits with 130. Once the
+	// first signal is handled, a second one kills hurl right away.
+	ctx, stopSignals := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stopSignals()
+	}()
+
+	// fetch performs the i-th transfer with the shared options. Like curl,
+	// the n-th -o applies to the n-th URL; the rest go to stdout.
+	fetch := func(i int, o outputOptions) int {
+		if ctx.Err() != nil {
+			return exitInterrupted // Skip the URLs after an interrupt
+		}
+		opts := reqOptions
+		opts.URL = urls[i]
+		if *optionsPtr {
+			// Without a path, OPTIONS asks about the server as a whole.
+			if u, err := url.Parse(opts.URL); err == nil && u.Path == "" && u.RawQuery == "" {
+				opts.RequestTarget = "*"
+			}
+		}
+		// Keeps verbose output and traces with the rest of this URL's output.
+		opts.Diagnostics = o.Diagnostics
+		if opts.Diagnostics == nil {
+			opts.Diagnostics = o.Stderr
+		}
+		opts.Trace = o.Trace
+		// With -O, a URL without a file name (and any URL with -J) is named
+		// after the response by transfer.
+		output := ""
+		if i < len(*outputsPtr) {
+			output = (*outputsPtr)[i]
+		} else if o.RemoteName && !o.HeaderName {
+			output = urlFileName(urls[i])
+		}
+		if output != "" && o.OutputDir != "" && !filepath.IsAbs(output) {
+			output = filepath.Join(o.OutputDir, output)
+		}
+
+		if len(urls) > 1 && output == "" && !o.RemoteName && !o.JSON {
+			if i > 0 {
+				fmt.Fprintln(o.Stdout)
+			}
+			fmt.Fprintf(o.Stdout, "%s==> %s <==%s\n", outCfg.GetAnsiCode(outCfg.HeaderKeyColor), urls[i], outCfg.ResetCode())
+		}
+
+		// withBody returns opts with a fresh request body, for each request.
+		withBody := func(opts network.RequestOptions) (network.RequestOptions, error) {
+			if newBody != nil {
+				body, contentType, err := newBody()
+				if err != nil {
+					return opts, err
+				}
+				opts.Body = body
+				opts.ContentType = contentType
+			}
+			return opts, nil
+		}
+
+		if flag.CommandLine.Changed("continue-at") {
+			offset, err := resumeOffset(*continueAtPtr, output)
+			if err != nil {
+				o.errorf("Error: --continue-at: %v", err)
+				return 1
+			}
+			opts.ResumeFrom = offset
+		}
+		if *wsProbePtr {
+			return probeWebSocket(ctx, opts, o)
+		}
+		if *ssePtr {
+			return streamEvents(ctx, o, func() (network.RequestOptions, error) {
+				return withBody(opts)
+			})
+		}
+		if *repeatPtr > 1 {
+			return benchmark(ctx, *repeatPtr, *repeatDelayPtr, o, func() (network.RequestOptions, error) {
+				return withBody(opts)
+			})
+		}
+		opts, err := withBody(opts)
+		if err != nil {
+			o.errorf("Error: %v", err)
+			return 1
+		}
+		return transfer(ctx, opts, output, o)
+	}
+
+	if *metricsOutPtr != "" {
+		outOptions.Metrics, err = newMetricsWriter(*metricsOutPtr)
+		if err != nil {
+			fatalf(1, "Error: --metrics-out: %v", err)
+		}
+	}
+
+	codes := make([]int, len(urls))
+	if parallel {
+		fetchParallel(len(urls), *parallelMaxPtr, outOptions, fetch, codes)
+	} else {
+		for i := range urls {
+			codes[i] = fetch(i, outOptions)
+		}
+	}
 
-		display.PrintHeaders(os.Stdout, resp.Header, cfg)
+	interrupted := ctx.Err() != nil
+	stopSignals()
+
+	exitCode := 0
+	for _, code := range codes {
+		if code != 0 {
+			exitCode = code
+		}
+	}
+	if interrupted {
+		exitCode = exitInterrupted
 	}
 
-	if resp.StatusCode >= 400 {
-		// os.Exit(2) // Optional: exit non-zero for >= 400 status codes
+	if traceFile != nil {
+		traceFile.Close()
+	}
+	if diagFile != nil {
+		diagFile.Close()
+	}
+	if outOptions.Metrics != nil {
+		if err := outOptions.Metrics.Close(); err != nil && showErrors {
+			fmt.Fprintf(os.Stderr, "%sError: --metrics-out: %v%s\n", stderrConfig.GetAnsiCode("red"), err, stderrConfig.ResetCode())
+			exitCode = 1
+		}
+	}
+	os.Exit(exitCode)
+}
+
+// stderrConfig colors the messages main prints to stderr. Until the config
+// file is loaded it holds the defaults; Color follows --color.
+var stderrConfig = config.DefaultConfig()
+
+// configWarnings returns a message for each problem LoadConfig recovered
+// from, followed by what it did instead.
+func configWarnings(err error) []string {
+	errs := []error{err}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok && errors.Is(err, config.ErrConfigDecode) {
+		errs = joined.Unwrap()
 	}
-}
\ No newline at end of file
+	var warnings []string
+	for _, err := range errs {
+		fallback := "Using default colors"
+		var fileErr *config.FileError
+		if errors.As(err, &fileErr) {
+			fallback = fileErr.Fallback
+		}
+		warnings = append(warnings, fmt.Sprintf("%v. %s", err, fallback))
+	}
+	return warnings
+}
+
+// expandURLs expands the globs in every URL argument, in order, unless
+// globoff (-g) is set, in which case the URLs are returned as they are.
+func expandURLs(urls []string, globoff bool) ([]string, error) {
+	if globoff {
+		return urls, nil
+	}
+	var expanded []string
+	for _, u := range urls {
+		globbed, err := network.ExpandURLGlob(u)
+		if err != nil {
+			return nil, err
+		}
+		expanded = append(expanded, globbed...)
+	}
+	return expanded, nil
+}
+
+// colorEnabled resolves a --color mode for the given stream: "auto" colors
+// only terminals.
+func colorEnabled(mode string, f *os.File) bool {
+	switch mode {
+	case "always":
+		return true
+	case "never":
+		return false
+	default:
+		return term.IsTerminal(int(f.Fd()))
+	}
+}
+
+// showErrors is cleared by -s (unless -S is also given) to keep error
+// messages off stderr.
+var showErrors = true
+
+// printError prints an error message in red to stderr, unless errors are
+// silenced.
+func printError(format string, args ...any) {
+	if showErrors {
+		fmt.Fprintf(os.Stderr, "%s%s%s\n", stderrConfig.GetAnsiCode("red"), fmt.Sprintf(format, args...), stderrConfig.ResetCode())
+	}
+}
+
+// fatalf prints an error message like printError and exits with the given code.
+func fatalf(code int, format string, args ...any) {
+	printError(format, args...)
+	os.Exit(code)
+}
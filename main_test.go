@@ -0,0 +1,53 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mclellac/hurl/config"
+)
+
+func TestConfigWarnings(t *testing.T) {
+	colorErr := &config.FileError{Path: "c.json", Field: "header_key_color", Err: errors.New("unknown color"), Fallback: `Using default color "yellow"`}
+	headerErr := &config.FileError{Path: "c.json", Field: "default_headers", Err: errors.New("bad header"), Fallback: "Ignoring header"}
+	tests := []struct {
+		name string
+		err  error
+		want []string
+	}{
+		{"one problem", colorErr, []string{`config file c.json: header_key_color: unknown color. Using default color "yellow"`}},
+		{"several problems", errors.Join(colorErr, headerErr), []string{
+			`config file c.json: header_key_color: unknown color. Using default color "yellow"`,
+			"config file c.json: default_headers: bad header. Ignoring header",
+		}},
+		{"other error", fmt.Errorf("could not open config file: %w", errors.New("permission denied")), []string{
+			"could not open config file: permission denied. Using default colors",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := configWarnings(tt.err); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("configWarnings = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandURLs(t *testing.T) {
+	urls := []string{"http://h/[1-2]", "http://h/{a,b}?q=[x]"}
+	got, err := expandURLs(urls, true)
+	if err != nil || !reflect.DeepEqual(got, urls) {
+		t.Errorf("expandURLs with -g = %q, %v; want %q unchanged", got, err, urls)
+	}
+
+	got, err = expandURLs(urls[:1], false)
+	if want := []string{"http://h/1", "http://h/2"}; err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("expandURLs = %q, %v; want %q", got, err, want)
+	}
+	if _, err := expandURLs(urls, false); err == nil || !strings.Contains(err.Error(), "use -g to turn off globbing") {
+		t.Errorf("expandURLs(%q) error = %v, want a bad range error", urls, err)
+	}
+}
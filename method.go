@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// methodFlags holds the command-line flags that decide the request method.
+type methodFlags struct {
+	Method   string // -X value
+	Explicit bool   // -X was given
+	Head     bool   // -I
+	Options  bool   // --options
+	Get      bool   // -G
+	HasBody  bool   // -d, --json or -F supplies a request body
+	AllowAny bool   // --allow-any-method: accept methods outside knownMethods
+}
+
+// knownMethods are the methods -X accepts without --allow-any-method.
+var knownMethods = map[string]bool{
+	"GET": true, "POST": true, "PUT": true, "DELETE": true, "PATCH": true,
+	"HEAD": true, "OPTIONS": true, "TRACE": true, "CONNECT": true,
+}
+
+// resolveMethod picks the request method the way curl does: --options sends
+// OPTIONS, -I sends HEAD (overriding -X), and a request body implies POST unless -X or -G says
+// otherwise. A HEAD request cannot carry a body, so -I with data is an
+// error unless -G moves the data into the URL. The -X value is uppercased
+// and must be a valid token and, without AllowAny, a known method.
+func resolveMethod(f methodFlags) (string, error) {
+	method := strings.ToUpper(f.Method)
+	if f.Explicit {
+		if err := validateMethod(method, f.AllowAny); err != nil {
+			return "", err
+		}
+	}
+
+	switch {
+	case f.Options && f.Head:
+		return "", errors.New("--options and -I/--head cannot be used together")
+	case f.Options && f.Explicit && method != "OPTIONS":
+		return "", fmt.Errorf("--options sends an OPTIONS request and cannot be combined with -X %s", method)
+	case f.Options:
+		return "OPTIONS", nil
+	case f.Head && f.HasBody && !f.Get:
+		return "", errors.New("-I/--head sends a HEAD request, which cannot carry the data given with -d, --json or -F (add -G to send -d data in the URL)")
+	case f.Head:
+		return "HEAD", nil
+	case f.HasBody && !f.Explicit && !f.Get:
+		return "POST", nil
+	}
+	return method, nil
+}
+
+// validateMethod checks that method is an RFC 7230 token and, unless
+// allowAny is set, one of knownMethods.
+func validateMethod(method string, allowAny bool) error {
+	if method == "" {
+		return errors.New("-X needs a method name")
+	}
+	for _, c := range []byte(method) {
+		if !isTokenChar(c) {
+			return fmt.Errorf("invalid method %q: only letters, digits and !#$%%&'*+-.^_`|~ are allowed", method)
+		}
+	}
+	if !allowAny && !knownMethods[method] {
+		return fmt.Errorf("unknown method %q (use --allow-any-method to send it anyway)", method)
+	}
+	return nil
+}
+
+// isTokenChar reports whether c may appear in an RFC 7230 token.
+func isTokenChar(c byte) bool {
+	switch {
+	case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		return true
+	}
+	return strings.IndexByte("!#$%&'*+-.^_`|~", c) >= 0
+}
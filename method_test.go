@@ -0,0 +1,47 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResolveMethod(t *testing.T) {
+	tests := []struct {
+		name    string
+		flags   methodFlags
+		want    string
+		wantErr string
+	}{
+		{"default", methodFlags{Method: "GET"}, "GET", ""},
+		{"-X lowercased", methodFlags{Method: "put", Explicit: true}, "PUT", ""},
+		{"data implies POST", methodFlags{Method: "GET", HasBody: true}, "POST", ""},
+		{"-X wins over data", methodFlags{Method: "PUT", Explicit: true, HasBody: true}, "PUT", ""},
+		{"-G keeps GET with data", methodFlags{Method: "GET", Get: true, HasBody: true}, "GET", ""},
+		{"-I", methodFlags{Method: "GET", Head: true}, "HEAD", ""},
+		{"-I overrides -X", methodFlags{Method: "POST", Explicit: true, Head: true}, "HEAD", ""},
+		{"-I with -G data", methodFlags{Method: "GET", Head: true, Get: true, HasBody: true}, "HEAD", ""},
+		{"-I with data", methodFlags{Method: "GET", Head: true, HasBody: true}, "", "cannot carry the data"},
+		{"--options", methodFlags{Method: "GET", Options: true}, "OPTIONS", ""},
+		{"--options with -X OPTIONS", methodFlags{Method: "options", Explicit: true, Options: true}, "OPTIONS", ""},
+		{"--options with -X GET", methodFlags{Method: "GET", Explicit: true, Options: true}, "", "cannot be combined with -X GET"},
+		{"--options with -I", methodFlags{Method: "GET", Options: true, Head: true}, "", "cannot be used together"},
+		{"unknown method", methodFlags{Method: "PURGE", Explicit: true}, "", "unknown method"},
+		{"unknown method allowed", methodFlags{Method: "PURGE", Explicit: true, AllowAny: true}, "PURGE", ""},
+		{"invalid token", methodFlags{Method: "GET X", Explicit: true, AllowAny: true}, "", "invalid method"},
+		{"empty -X", methodFlags{Method: "", Explicit: true}, "", "needs a method name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveMethod(tt.flags)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("resolveMethod error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("resolveMethod = %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mclellac/hurl/network"
+)
+
+// metricsColumns are the fields of a --metrics-out sample, in CSV order.
+var metricsColumns = []string{"attempt", "status", "dns_ms", "connect_ms", "tls_ms", "ttfb_ms", "total_ms", "bytes", "url"}
+
+// metricsWriter writes one timing sample per --repeat request to a file,
+// as CSV or, for a .json or .jsonl file, as one JSON object per line. Each
+// sample is flushed as soon as it is written, so the file is complete up
+// to the last request even if the run is cut short. It is safe for
+// concurrent use, as with --parallel.
+type metricsWriter struct {
+	mu   sync.Mutex
+	f    *os.File
+	csv  *csv.Writer // nil when writing JSON
+	json *json.Encoder
+}
+
+// newMetricsWriter creates path and, for CSV, writes the header row.
+func newMetricsWriter(path string) (*metricsWriter, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	m := &metricsWriter{f: f}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json", ".jsonl":
+		m.json = json.NewEncoder(f)
+	default:
+		m.csv = csv.NewWriter(f)
+		m.csv.Write(metricsColumns)
+		m.csv.Flush()
+		if err := m.csv.Error(); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// record writes the sample for one request. A request that failed gets
+// status 0 and no timings.
+func (m *metricsWriter) record(url string, attempt, status int, t network.Timings, bytes int64) error {
+	ms := func(d time.Duration) float64 { return float64(d.Microseconds()) / 1000 }
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.json != nil {
+		return m.json.Encode(struct {
+			Attempt   int     `json:"attempt"`
+			Status    int     `json:"status"`
+			DNSMs     float64 `json:"dns_ms"`
+			ConnectMs float64 `json:"connect_ms"`
+			TLSMs     float64 `json:"tls_ms"`
+			TTFBMs    float64 `json:"ttfb_ms"`
+			TotalMs   float64 `json:"total_ms"`
+			Bytes     int64   `json:"bytes"`
+			URL       string  `json:"url"`
+		}{attempt, status, ms(t.DNS), ms(t.Connect), ms(t.TLS), ms(t.FirstByte), ms(t.Total), bytes, url})
+	}
+	format := func(d time.Duration) string { return strconv.FormatFloat(ms(d), 'f', 3, 64) }
+	m.csv.Write([]string{
+		strconv.Itoa(attempt), strconv.Itoa(status),
+		format(t.DNS), format(t.Connect), format(t.TLS), format(t.FirstByte), format(t.Total),
+		strconv.FormatInt(bytes, 10), url,
+	})
+	m.csv.Flush()
+	return m.csv.Error()
+}
+
+// Close closes the file.
+func (m *metricsWriter) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.f.Close(); err != nil {
+		return fmt.Errorf("error closing %s: %w", m.f.Name(), err)
+	}
+	return nil
+}
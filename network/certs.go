@@ -0,0 +1,78 @@
+package network
+
+import (
+	"crypto/x509"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/mclellac/hurl/config"
+)
+
+// printCertChain prints the certificates a server sent, leaf first: the
+// subject, issuer, validity, subject alternative names, serial number and
+// signature algorithm of each, and the public key pin of the leaf.
+func printCertChain(w io.Writer, certs []*x509.Certificate, cfg config.Config) {
+	valueColor := cfg.GetAnsiCode(cfg.HeaderValueColor)
+	traceColor := cfg.GetAnsiCode("white")
+	resetColor := cfg.ResetCode()
+	field := func(name, value string) {
+		fmt.Fprintf(w, "%s*   %s: %s%s%s\n", traceColor, name, valueColor, value, resetColor)
+	}
+
+	fmt.Fprintf(w, "%s* Server certificate chain (%d):%s\n", traceColor, len(certs), resetColor)
+	for i, cert := range certs {
+		fmt.Fprintf(w, "%s* %d Subject: %s%s%s\n", traceColor, i, valueColor, cert.Subject.String(), resetColor)
+		field("Issuer", cert.Issuer.String())
+		field("Valid from", cert.NotBefore.Format(time.RFC1123))
+		field("Expiry", cert.NotAfter.Format(time.RFC1123))
+		if sans := certSANs(cert); len(sans) > 0 {
+			field("SANs", strings.Join(sans, ", "))
+		}
+		field("Serial", certSerial(cert))
+		field("Signature", cert.SignatureAlgorithm.String())
+		if i == 0 {
+			field("Public key pin", PublicKeyPin(cert))
+		}
+	}
+}
+
+// certSANs lists the subject alternative names of cert.
+func certSANs(cert *x509.Certificate) []string {
+	sans := append([]string{}, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+	sans = append(sans, cert.EmailAddresses...)
+	for _, u := range cert.URIs {
+		sans = append(sans, u.String())
+	}
+	return sans
+}
+
+// certSerial formats the serial number of cert as colon-separated hex
+// bytes, as openssl prints it.
+func certSerial(cert *x509.Certificate) string {
+	b := cert.SerialNumber.Bytes()
+	if len(b) == 0 {
+		return "00"
+	}
+	parts := make([]string, len(b))
+	for i, c := range b {
+		parts[i] = fmt.Sprintf("%02X", c)
+	}
+	return strings.Join(parts, ":")
+}
+
+// DaysUntilExpiry returns the number of whole days left before cert
+// expires, or minus the number of days since it expired (at least -1, so
+// an expired certificate never gives 0).
+func DaysUntilExpiry(cert *x509.Certificate) int {
+	left := time.Until(cert.NotAfter)
+	days := int(left.Hours() / 24)
+	if left < 0 {
+		days = min(days, -1)
+	}
+	return days
+}
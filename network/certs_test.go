@@ -0,0 +1,76 @@
+package network
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDaysUntilExpiry(t *testing.T) {
+	tests := []struct {
+		name string
+		left time.Duration
+		want int
+	}{
+		{"in 90 days", 90*24*time.Hour + time.Hour, 90},
+		{"in under a day", 12 * time.Hour, 0},
+		{"just expired", -time.Minute, -1},
+		{"expired 10 days ago", -10*24*time.Hour - time.Hour, -10},
+	}
+	for _, tt := range tests {
+		cert := &x509.Certificate{NotAfter: time.Now().Add(tt.left)}
+		if got := DaysUntilExpiry(cert); got != tt.want {
+			t.Errorf("%s: DaysUntilExpiry = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+// expiringServer starts a TLS server whose self-signed certificate expires
+// at notAfter.
+func expiringServer(t *testing.T, notAfter time.Time) *httptest.Server {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		NotBefore:    time.Now().Add(-30 * 24 * time.Hour),
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.TLS = &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestDoRecordsPeerCertExpiry(t *testing.T) {
+	srv := expiringServer(t, time.Now().Add(5*24*time.Hour+time.Hour))
+	result, err := Do(RequestOptions{URL: srv.URL, InsecureSkipTLS: true})
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	result.Response.Body.Close()
+	if len(result.Info.PeerCerts) == 0 {
+		t.Fatal("no peer certificates recorded")
+	}
+	if got := DaysUntilExpiry(result.Info.PeerCerts[0]); got != 5 {
+		t.Errorf("DaysUntilExpiry of the served certificate = %d, want 5", got)
+	}
+}
@@ -2,14 +2,21 @@
 package network
 
 import (
+	"context"
 	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"net"
 	"net/http"
 	"net/http/httptrace"
+	"net/url"
 	"os"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/mclellac/hurl/config"
@@ -18,209 +25,851 @@ import (
 // akamaiPragmaValue is the static string used for the Akamai Pragma header.
 const akamaiPragmaValue = "akamai-x-get-request-id,akamai-x-get-cache-key,akamai-x-cache-on,akamai-x-cache-remote-on,akamai-x-get-true-cache-key,akamai-x-check-cacheable,akamai-x-get-extracted-values,akamai-x-feo-trace,x-akamai-logging-mode: verbose"
 
+// DefaultUserAgent is sent when RequestOptions.UserAgent is empty.
+const DefaultUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/135.0.0.0 Safari/537.36"
+
+// HTTP versions accepted by RequestOptions.HTTPVersion. HTTP/2 is only
+// negotiated over TLS (via ALPN); plain http:// requests use HTTP/1.1.
+const (
+	HTTPVersion11 = "1.1"
+	HTTPVersion2  = "2"
+)
+
+// Verbosity levels for RequestOptions.Verbose. Each level includes the
+// output of the levels below it.
+const (
+	VerboseLines      = 1 // Request and status lines, redirects, retries and failures
+	VerboseHeaders    = 2 // Request and response headers
+	VerboseConnection = 3 // Timeouts, proxy, DNS and connection trace
+	VerboseTLS        = 4 // TLS handshake and certificate details
+)
+
+// defaultConnectTimeout matches the dialer settings of http.DefaultTransport.
+const defaultConnectTimeout = 30 * time.Second
+
+// defaultKeepAlive is the TCP keep-alive interval of http.DefaultTransport.
+const defaultKeepAlive = 30 * time.Second
+
 // RequestOptions bundles parameters for making the HTTP request.
 type RequestOptions struct {
-	Method          string        // HTTP method (e.g., "GET", "POST")
-	URL             string        // Target URL
-	CustomHeaders   []string      // Custom headers in "Key: Value" format
-	InsecureSkipTLS bool          // If true, skip TLS certificate verification
-	FollowRedirects bool          // If true, follow HTTP 3xx redirects
-	AddAkamaiPragma bool          // If true, add the Akamai debug Pragma header
-	Verbose         bool          // If true, enable verbose output to stderr
-	Config          config.Config // Color configuration
+	Method          string          // HTTP method (e.g., "GET", "POST")
+	URL             string          // Target URL
+	RequestTarget   string          // If set, sent instead of the URL's path and query, e.g. "*" for OPTIONS *
+	CustomHeaders   []string        // Custom headers in "Key: Value" format
+	DefaultHeaders  []string        // Headers from the config file and conditional-request flags, in "Key: Value" format; CustomHeaders win
+	Body            io.Reader       // Optional request body
+	DataAsQuery     bool            // If true, append Body to the URL's query string instead of sending it
+	Chunked         bool            // If true, send Body with chunked transfer encoding even when its length is known
+	URLQuery        []string        // Query parameters in "name=value" format, URL-encoded and appended to the URL
+	ContentType     string          // Content-Type sent with Body unless set via CustomHeaders
+	Accept          string          // Accept header sent unless set via CustomHeaders
+	Range           string          // Byte ranges to request (e.g. "0-499"), sent as "Range: bytes=..."; see ValidateRange
+	ResumeFrom      int64           // If > 0, request the body from this byte offset to resume a download
+	MaxFileSize     int64           // If > 0, fail with ErrMaxFileSize when the (decoded) body is larger
+	RateLimit       int64           // If > 0, read the response body at most this many bytes per second
+	BasicAuthUser   string          // If non-empty, send HTTP basic auth credentials
+	BasicAuthPass   string          // Password used with BasicAuthUser
+	BearerToken     string          // If non-empty, send "Authorization: Bearer <token>"
+	Netrc           *Netrc          // If set, send basic auth credentials for the host from it when no others are given
+	UserAgent       string          // User-Agent to send; empty uses DefaultUserAgent
+	OmitUserAgent   bool            // If true, send no User-Agent header at all
+	Referer         string          // Referer header for the first request
+	AutoReferer     bool            // If true, set Referer to the previous URL when following redirects
+	Cookie          string          // Literal Cookie header value, e.g. "name=value; other=value"
+	CookieFile      string          // Netscape cookie file to read cookies from (a missing file is ignored)
+	CookieJarFile   string          // File to write all cookies to in Netscape format after the request
+	UnixSocket      string          // If set, connect to this Unix domain socket instead of the URL's host
+	Interface       string          // If set, bind outgoing connections to this local IP or network interface
+	ConnectTo       []ConnectTo     // Rules that send connections for one host and port to another
+	SNI             string          // If set, the TLS server name to send and verify instead of the URL's host
+	Proxy           string          // Proxy URL (http://, https://, socks5:// or socks5h://); empty uses HTTP_PROXY/HTTPS_PROXY/NO_PROXY
+	DoHURL          string          // If set, resolve host names with this DNS-over-HTTPS (RFC 8484) server
+	NoDNSCache      bool            // Resolve host names for every connection instead of caching them per transport
+	ClientCertFile  string          // PEM client certificate for mutual TLS (may also contain the key)
+	ClientKeyFile   string          // PEM private key for ClientCertFile; empty means the key is in ClientCertFile
+	PinnedPubKeys   []string        // If set, the server's public key must match one of these "sha256//BASE64" pins
+	TLSMinVersion   uint16          // Minimum TLS version (tls.VersionTLS12 etc.); 0 uses Go's default
+	TLSMaxVersion   uint16          // Maximum TLS version; 0 uses Go's default
+	InsecureSkipTLS bool            // If true, skip TLS certificate verification
+	ShowCert        bool            // If true, print the server's certificate chain even below VerboseTLS
+	Compressed      bool            // If true, request compressed responses and decode them; otherwise bodies are left untouched
+	HTTPVersion     string          // HTTPVersion11 or HTTPVersion2 to control protocol negotiation; empty uses Go's default
+	FollowRedirects bool            // If true, follow HTTP 3xx redirects
+	MaxRedirects    int             // Maximum redirects to follow with FollowRedirects; -1 means unlimited
+	LocationTrusted bool            // If true, send Authorization and Cookie headers to every host redirects lead to
+	AddAkamaiPragma bool            // If true, add the Akamai debug Pragma header
+	Verbose         int             // Verbosity level for diagnostics; 0 is quiet, see VerboseLines and up
+	Diagnostics     io.Writer       // Where verbose diagnostics are written; os.Stderr if nil
+	TraceTime       string          // TraceTimeRelative or TraceTimeAbsolute to prefix diagnostic lines with the time; empty adds none
+	Timeout         time.Duration   // Overall time limit for the request, including connection setup; 0 means no limit
+	ConnectTimeout  time.Duration   // Time limit for establishing the TCP connection; 0 uses defaultConnectTimeout
+	NoKeepAlive     bool            // If true, use a new connection for every request and send no TCP keep-alive probes
+	KeepAliveTime   time.Duration   // Interval between TCP keep-alive probes; 0 uses defaultKeepAlive
+	FallbackDelay   time.Duration   // Happy Eyeballs head start of the first address family before the other is raced; 0 uses 300ms, negative dials addresses one at a time
+	ExpectTimeout   time.Duration   // Wait for 100 Continue after "Expect: 100-continue" before sending the body; 0 keeps the 1s default, negative doesn't wait
+	Config          config.Config   // Color configuration for verbose output on stderr
+	Transport       *http.Transport // If non-nil, used instead of a transport built from these options; see NewTransport
+	Trace           io.Writer       // If non-nil, receives a plain-text dump of the request and response, including bodies
+	Retries         int             // Number of times to retry transient failures
+	RetryDelay      time.Duration   // Base delay between retries, doubled each attempt; 0 uses defaultRetryDelay
+	RetryOnStatus   []int           // Response statuses to retry; empty means 429 and 5xx
+	Timings         *Timings        // If non-nil, filled with the duration of each request phase
+	Info            *TransferInfo   // If non-nil, filled with connection and redirect details
 }
 
 // Fetch performs an HTTP request based on the provided options.
 // The caller is responsible for closing the response body if the returned response is non-nil.
 func Fetch(opts RequestOptions) (*http.Response, error) {
+	return FetchContext(context.Background(), opts)
+}
 
-	keyColor := config.GetAnsiCode(opts.Config.HeaderKeyColor)
-	valueColor := config.GetAnsiCode(opts.Config.HeaderValueColor)
-	traceColor := config.ColorWhite
-	errorColor := config.ColorRed
-	successColor := config.ColorGreen
-	warningColor := config.ColorYellow
-	resetColor := config.ColorReset
+// FetchContext is like Fetch, but the request, including retries and the
+// delays between them, is abandoned when ctx is done.
+func FetchContext(ctx context.Context, opts RequestOptions) (*http.Response, error) {
+	valueColor := opts.Config.GetAnsiCode(opts.Config.HeaderValueColor)
+	traceColor := opts.Config.GetAnsiCode("white")
+	errorColor := opts.Config.GetAnsiCode("red")
+	warningColor := opts.Config.GetAnsiCode("yellow")
+	resetColor := opts.Config.ResetCode()
+	diag := timestamped(diagnostics(opts), opts.TraceTime, time.Now())
 
-	tr := http.DefaultTransport.(*http.Transport).Clone()
-	if tr.TLSClientConfig == nil {
-		tr.TLSClientConfig = &tls.Config{}
+	tr := opts.Transport
+	if tr == nil {
+		var err error
+		tr, err = NewTransport(opts)
+		if err != nil {
+			return nil, err
+		}
 	}
-	tr.TLSClientConfig.InsecureSkipVerify = opts.InsecureSkipTLS
 
 	client := &http.Client{
-		Timeout:   30 * time.Second,
+		Timeout:   opts.Timeout,
 		Transport: tr,
 	}
 
-	// This logic remains correct: if FollowRedirects is false (now the default unless -L is passed),
-	// set CheckRedirect to prevent following. Otherwise, use default behavior.
-	if !opts.FollowRedirects {
-		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
-			if opts.Verbose {
-				fmt.Fprintf(os.Stderr, "%s* Ignoring redirect response from %s%s\n", traceColor, req.URL, resetColor)
+	if opts.Verbose >= VerboseConnection {
+		if opts.Timeout > 0 {
+			fmt.Fprintf(diag, "%s* Timeout: %s%s%s\n", traceColor, valueColor, opts.Timeout, resetColor)
+		} else {
+			fmt.Fprintf(diag, "%s* Timeout: %snone%s\n", traceColor, valueColor, resetColor)
+		}
+		fmt.Fprintf(diag, "%s* Connect timeout: %s%s%s\n", traceColor, valueColor, connectTimeout(opts), resetColor)
+		if opts.UnixSocket != "" {
+			fmt.Fprintf(diag, "%s* Connecting via Unix socket %s%s%s instead of TCP\n", traceColor, valueColor, opts.UnixSocket, resetColor)
+		}
+		if local, err := localBindAddr(opts.Interface); opts.Interface != "" && err == nil {
+			fmt.Fprintf(diag, "%s* Binding to local address %s%s%s\n", traceColor, valueColor, local.IP, resetColor)
+		}
+		if proxyURL, _ := parseProxyURL(opts.Proxy); proxyURL != nil && opts.UnixSocket == "" {
+			fmt.Fprintf(diag, "%s* Using proxy %s%s%s\n", traceColor, valueColor, proxyURL.Redacted(), resetColor)
+		}
+		if opts.SNI != "" {
+			fmt.Fprintf(diag, "%s* Sending TLS server name (SNI) %s%s%s\n", traceColor, valueColor, opts.SNI, resetColor)
+		}
+		if opts.DoHURL != "" {
+			fmt.Fprintf(diag, "%s* Resolving host names with DNS-over-HTTPS via %s%s%s\n", traceColor, valueColor, opts.DoHURL, resetColor)
+		}
+	}
+
+	// Redirects are only followed with -L, and then at most MaxRedirects times.
+	// A limit of 0 behaves as if -L had not been given.
+	info := opts.Info
+	if info == nil {
+		info = &TransferInfo{}
+	}
+	// net/http sets Referer on every redirect by itself; hurl only does so
+	// with AutoReferer, like curl. initialReferer is filled in once the
+	// request headers are known.
+	var initialReferer string
+	var refererFromHeader bool
+	// Credentials given on the command line, also filled in with the
+	// request headers. net/http drops them when a redirect leaves the
+	// original domain but keeps them for its subdomains; hurl drops them on
+	// any change of host or port, like curl, or sends them to every host
+	// with LocationTrusted.
+	var credentials http.Header
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if !opts.FollowRedirects || opts.MaxRedirects == 0 {
+			if opts.Verbose >= VerboseLines {
+				fmt.Fprintf(diag, "%s* Ignoring redirect response from %s%s\n", traceColor, req.URL, resetColor)
 			}
 			return http.ErrUseLastResponse
 		}
+		if opts.MaxRedirects > 0 && len(via) > opts.MaxRedirects {
+			return fmt.Errorf("maximum (%d) redirects followed, not following redirect from %s", opts.MaxRedirects, via[len(via)-1].URL)
+		}
+		// req.Response is the redirect being followed. The chain can only
+		// grow to MaxRedirects hops, as the check above stops it there.
+		hop := RedirectHop{From: via[len(via)-1].URL.String(), To: req.URL.String()}
+		if req.Response != nil {
+			hop.Status = req.Response.StatusCode
+		}
+		if opts.Verbose >= VerboseLines {
+			fmt.Fprintf(diag, "%s* Following redirect %d (%s%d%s) from %s to %s%s%s\n", traceColor, len(via), valueColor, hop.Status, traceColor, hop.From, valueColor, hop.To, resetColor)
+		}
+		switch {
+		case opts.AutoReferer && !refererFromHeader:
+			prev := *via[len(via)-1].URL
+			prev.User = nil
+			prev.Fragment = ""
+			req.Header.Set("Referer", prev.String())
+		case initialReferer != "":
+			req.Header.Set("Referer", initialReferer)
+		default:
+			req.Header.Del("Referer")
+		}
+		if origin := via[0].URL; len(credentials) > 0 && !sameHost(req.URL, origin) {
+			if opts.LocationTrusted {
+				for key, values := range credentials {
+					req.Header[key] = values
+				}
+				if opts.Verbose >= VerboseLines {
+					fmt.Fprintf(diag, "%s* Sending credentials to %s as well (--location-trusted)%s\n", warningColor, req.URL.Host, resetColor)
+				}
+			} else {
+				for key := range credentials {
+					req.Header.Del(key)
+				}
+				if opts.Verbose >= VerboseLines {
+					fmt.Fprintf(diag, "%s* Not sending credentials to %s, a different host than %s (see --location-trusted)%s\n", traceColor, req.URL.Host, origin.Host, resetColor)
+				}
+			}
+		}
+		info.Hops = append(info.Hops[:len(via)-1], hop)
+		return nil
+	}
+
+	// Cookie files are handled through a jar so responses can update them.
+	var jar *CookieJar
+	if opts.CookieFile != "" || opts.CookieJarFile != "" {
+		var err error
+		jar, err = NewCookieJar()
+		if err != nil {
+			return nil, err
+		}
+		if opts.CookieFile != "" {
+			if err := jar.Load(opts.CookieFile); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return nil, fmt.Errorf("error loading cookies: %w", err)
+			}
+		}
+		client.Jar = jar
+	}
+
+	body := opts.Body
+	query := ""
+	if opts.DataAsQuery && body != nil {
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return nil, fmt.Errorf("error reading request data: %w", err)
+		}
+		body = nil
+		query = string(data)
 	}
 
-	req, err := http.NewRequest(opts.Method, opts.URL, nil)
+	// The resolver and dialer report through the request's context.
+	ctx = context.WithValue(ctx, dialTraceKey{}, dialTrace{w: diag, verbose: opts.Verbose, color: traceColor, reset: resetColor})
+	req, err := http.NewRequestWithContext(ctx, opts.Method, opts.URL, body)
 	if err != nil {
 		return nil, fmt.Errorf("error creating request: %w", err)
 	}
+	if query != "" {
+		req.URL.RawQuery = appendQuery(req.URL.RawQuery, query)
+	}
+	if len(opts.URLQuery) > 0 {
+		req.URL.RawQuery = appendQuery(req.URL.RawQuery, encodeQueryParams(opts.URLQuery))
+	}
+	if opts.RequestTarget != "" {
+		// net/http writes an opaque URL as the request target verbatim.
+		req.URL.Opaque = opts.RequestTarget
+	}
+	if opts.Chunked && body != nil {
+		// An unknown length keeps net/http from sending Content-Length;
+		// HTTP/2 streams the body in DATA frames instead.
+		req.ContentLength = -1
+		req.TransferEncoding = []string{"chunked"}
+	}
 
-	userAgent := "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/135.0.0.0 Safari/537.36"
+	userAgent := opts.UserAgent
+	if userAgent == "" {
+		userAgent = DefaultUserAgent
+	}
 	req.Header.Set("User-Agent", userAgent)
+	if opts.OmitUserAgent {
+		// Deleting the header would make net/http add its own Go-http-client
+		// User-Agent; an empty value makes it send none at all.
+		req.Header.Set("User-Agent", "")
+	}
 
-	for _, h := range opts.CustomHeaders {
-		parts := strings.SplitN(h, ":", 2)
-		if len(parts) == 2 {
-			key := strings.TrimSpace(parts[0])
-			value := strings.TrimSpace(parts[1])
-			if key != "" {
-				req.Header.Add(key, value)
+	// Implied headers are set first so that headers supplied via -H win.
+	if body != nil && opts.ContentType != "" {
+		req.Header.Set("Content-Type", opts.ContentType)
+	}
+	if opts.Accept != "" {
+		req.Header.Set("Accept", opts.Accept)
+	}
+	if opts.Compressed {
+		req.Header.Set("Accept-Encoding", AcceptEncoding)
+	}
+	if opts.Range != "" {
+		req.Header.Set("Range", "bytes="+opts.Range)
+	} else if opts.ResumeFrom > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", opts.ResumeFrom))
+	}
+	if opts.Cookie != "" {
+		req.Header.Set("Cookie", opts.Cookie)
+	}
+	if opts.BasicAuthUser != "" {
+		req.SetBasicAuth(opts.BasicAuthUser, opts.BasicAuthPass)
+	} else if opts.Netrc != nil && opts.BearerToken == "" && req.URL.User == nil {
+		if login, password, ok := opts.Netrc.Lookup(req.URL.Hostname()); ok && login != "" {
+			req.SetBasicAuth(login, password)
+			if opts.Verbose >= VerboseConnection {
+				fmt.Fprintf(diag, "%s* Using credentials for user %s%s%s from %s%s\n", traceColor, valueColor, login, traceColor, opts.Netrc.Path, resetColor)
 			}
-		} else if len(parts) == 1 && strings.TrimSpace(parts[0]) != "" {
-			// Handle headers with empty value, like "X-Custom-Flag;"
-			key := strings.TrimRight(strings.TrimSpace(parts[0]), ";")
-			req.Header.Add(key, "")
 		}
 	}
+	if opts.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+opts.BearerToken)
+	}
+
+	if opts.Referer != "" {
+		req.Header.Set("Referer", opts.Referer)
+	}
+
+	applyCustomHeaders(req.Header, mergeDefaultHeaders(opts.DefaultHeaders, opts.CustomHeaders))
+	initialReferer = req.Header.Get("Referer")
+	credentials = make(http.Header)
+	for _, key := range []string{"Authorization", "Cookie"} {
+		if values := req.Header.Values(key); len(values) > 0 {
+			credentials[key] = values
+		}
+	}
+	refererFromHeader = initialReferer != "" && initialReferer != opts.Referer
 
 	if opts.AddAkamaiPragma {
 		req.Header.Set("Pragma", akamaiPragmaValue)
 	}
 
-	var trace *httptrace.ClientTrace
+	// The trace is always attached so phase timings can be captured;
+	// the diagnostic lines are only printed in verbose mode.
+	timings := opts.Timings
+	if timings == nil {
+		timings = &Timings{}
+	}
 	currentReq := req
-	if opts.Verbose {
-		trace = &httptrace.ClientTrace{
-			GetConn: func(hostPort string) {
-				fmt.Fprintf(os.Stderr, "%s* Trying %s...%s\n", traceColor, hostPort, resetColor)
-			},
-			DNSStart: func(info httptrace.DNSStartInfo) {
-				fmt.Fprintf(os.Stderr, "%s* Resolving %s...%s\n", traceColor, info.Host, resetColor)
-			},
-			DNSDone: func(info httptrace.DNSDoneInfo) {
-				if info.Err != nil {
-					fmt.Fprintf(os.Stderr, "%s* Error resolving host %s: %v%s\n", errorColor, currentReq.URL.Host, info.Err, resetColor)
-					return
-				}
-				addrs := []string{}
-				for _, ip := range info.Addrs {
-					addrs = append(addrs, ip.String())
+	got100 := false
+	// With Happy Eyeballs the dialer may try IPv6 and IPv4 addresses at
+	// the same time, so the connect callbacks can run concurrently.
+	var dialMu sync.Mutex
+	dialing := 0
+	dialFamilies := make(map[string]bool)
+	trace := &httptrace.ClientTrace{
+		GetConn: func(hostPort string) {
+			if opts.Verbose >= VerboseConnection {
+				fmt.Fprintf(diag, "%s* Trying %s...%s\n", traceColor, hostPort, resetColor)
+			}
+		},
+		DNSStart: func(info httptrace.DNSStartInfo) {
+			timings.dnsStart = time.Now()
+			if opts.Verbose >= VerboseConnection {
+				fmt.Fprintf(diag, "%s* Resolving %s...%s\n", traceColor, info.Host, resetColor)
+			}
+		},
+		DNSDone: func(info httptrace.DNSDoneInfo) {
+			timings.DNS += time.Since(timings.dnsStart)
+			if opts.Verbose < VerboseConnection {
+				return
+			}
+			if info.Err != nil {
+				fmt.Fprintf(diag, "%s* Error resolving host %s: %v%s\n", errorColor, currentReq.URL.Host, info.Err, resetColor)
+				return
+			}
+			addrs := []string{}
+			for _, ip := range info.Addrs {
+				addrs = append(addrs, ip.String())
+			}
+			fmt.Fprintf(diag, "%s* Resolved %s to %s%v%s\n", traceColor, currentReq.URL.Host, valueColor, addrs, resetColor)
+		},
+		ConnectStart: func(network, addr string) {
+			dialMu.Lock()
+			if dialing == 0 {
+				timings.connectStart = time.Now()
+				clear(dialFamilies)
+			}
+			dialing++
+			dialFamilies[addrFamily(addr)] = true
+			dialMu.Unlock()
+			if opts.Verbose >= VerboseConnection {
+				fmt.Fprintf(diag, "%s* Connecting to %s%s (%s)%s\n", traceColor, valueColor, addr, network, resetColor)
+			}
+		},
+		ConnectDone: func(network, addr string, err error) {
+			// The dialer cancels the slower attempt once one has connected.
+			lostRace := errors.Is(err, context.Canceled) && ctx.Err() == nil
+			dialMu.Lock()
+			dialing--
+			if !lostRace {
+				timings.Connect += time.Since(timings.connectStart)
+			}
+			dialMu.Unlock()
+			if opts.Verbose < VerboseConnection {
+				return
+			}
+			if lostRace {
+				fmt.Fprintf(diag, "%s* Abandoned connecting to %s: another address connected first%s\n", traceColor, addr, resetColor)
+			} else if err != nil {
+				fmt.Fprintf(diag, "%s* Error connecting to %s: %v%s\n", errorColor, addr, err, resetColor)
+			} else {
+				fmt.Fprintf(diag, "%s* Connected to %s%s (%s)%s\n", traceColor, valueColor, addr, currentReq.URL.Host, resetColor)
+			}
+		},
+		TLSHandshakeStart: func() {
+			timings.tlsStart = time.Now()
+			if opts.Verbose >= VerboseTLS {
+				fmt.Fprintf(diag, "%s* Performing TLS handshake...%s\n", traceColor, resetColor)
+			}
+		},
+		TLSHandshakeDone: func(cs tls.ConnectionState, err error) {
+			timings.TLS += time.Since(timings.tlsStart)
+			if err != nil && opts.Verbose >= VerboseConnection {
+				fmt.Fprintf(diag, "%s* TLS handshake error: %v%s\n", errorColor, err, resetColor)
+			}
+			if cs.Version == 0 {
+				return
+			}
+			if opts.Verbose < VerboseTLS {
+				// --show-cert prints the chain without the rest of the TLS details.
+				if opts.ShowCert && len(cs.PeerCertificates) > 0 {
+					printCertChain(diag, cs.PeerCertificates, opts.Config)
 				}
-				fmt.Fprintf(os.Stderr, "%s* Resolved %s to %s%v%s\n", traceColor, currentReq.URL.Host, valueColor, addrs, resetColor)
-			},
-			ConnectStart: func(network, addr string) {
-				fmt.Fprintf(os.Stderr, "%s* Connecting to %s%s (%s)%s\n", traceColor, valueColor, addr, network, resetColor)
-			},
-			ConnectDone: func(network, addr string, err error) {
-				if err != nil {
-					fmt.Fprintf(os.Stderr, "%s* Error connecting to %s: %v%s\n", errorColor, addr, err, resetColor)
-				} else {
-					fmt.Fprintf(os.Stderr, "%s* Connected to %s%s (%s)%s\n", traceColor, valueColor, addr, currentReq.URL.Host, resetColor)
+				return
+			}
+			proto := ""
+			switch cs.Version {
+			case tls.VersionTLS10:
+				proto = "TLSv1.0"
+			case tls.VersionTLS11:
+				proto = "TLSv1.1"
+			case tls.VersionTLS12:
+				proto = "TLSv1.2"
+			case tls.VersionTLS13:
+				proto = "TLSv1.3"
+			default:
+				proto = fmt.Sprintf("TLS Unknown (0x%x)", cs.Version)
+			}
+			fmt.Fprintf(diag, "%s* TLS handshake complete%s\n", traceColor, resetColor)
+			fmt.Fprintf(diag, "%s* Protocol: %s%s%s\n", traceColor, valueColor, proto, resetColor)
+			fmt.Fprintf(diag, "%s* Cipher Suite: %s%s%s\n", traceColor, valueColor, tls.CipherSuiteName(cs.CipherSuite), resetColor)
+			if len(cs.PeerCertificates) > 0 {
+				printCertChain(diag, cs.PeerCertificates, opts.Config)
+			}
+			if cs.NegotiatedProtocol != "" {
+				fmt.Fprintf(diag, "%s* ALPN: server accepted %s%s%s\n", traceColor, valueColor, cs.NegotiatedProtocol, resetColor)
+			}
+
+		},
+		GotConn: func(conn httptrace.GotConnInfo) {
+			info.RemoteAddr = conn.Conn.RemoteAddr().String()
+			info.LocalAddr = conn.Conn.LocalAddr().String()
+			if tlsConn, ok := conn.Conn.(*tls.Conn); ok {
+				info.PeerCerts = tlsConn.ConnectionState().PeerCertificates
+			}
+			if opts.Verbose < VerboseConnection {
+				return
+			}
+			dialMu.Lock()
+			raced := len(dialFamilies) > 1
+			dialMu.Unlock()
+			if raced && !conn.Reused {
+				fmt.Fprintf(diag, "%s* Happy Eyeballs: %s%s%s connected first%s\n", traceColor, valueColor, addrFamily(info.RemoteAddr), traceColor, resetColor)
+			}
+			if conn.Reused {
+				// A connection that was not idle is shared, as with HTTP/2.
+				idle := ""
+				if conn.WasIdle {
+					idle = fmt.Sprintf(" %s(idle %s)", traceColor, conn.IdleTime.Round(time.Millisecond))
 				}
-			},
-			TLSHandshakeStart: func() {
-				fmt.Fprintf(os.Stderr, "%s* Performing TLS handshake...%s\n", traceColor, resetColor)
-			},
-			TLSHandshakeDone: func(cs tls.ConnectionState, err error) {
-				if err != nil {
-					fmt.Fprintf(os.Stderr, "%s* TLS handshake error: %v%s\n", errorColor, err, resetColor)
-					if cs.Version == 0 {
-						return
+				fmt.Fprintf(diag, "%s* Re-using existing connection to %s%s%s from %s%s%s%s\n", traceColor, valueColor, info.RemoteAddr, traceColor, valueColor, info.LocalAddr, idle, resetColor)
+			} else {
+				fmt.Fprintf(diag, "%s* Connection established to %s%s%s from %s%s%s\n", traceColor, valueColor, info.RemoteAddr, traceColor, valueColor, info.LocalAddr, resetColor)
+			}
+		},
+		Wait100Continue: func() {
+			if opts.Verbose >= VerboseConnection && opts.ExpectTimeout >= 0 {
+				fmt.Fprintf(diag, "%s* Waiting for 100 Continue before sending the body...%s\n", traceColor, resetColor)
+			}
+		},
+		Got100Continue: func() {
+			got100 = true
+			if opts.Verbose >= VerboseConnection {
+				fmt.Fprintf(diag, "%s* Received 100 Continue%s\n", traceColor, resetColor)
+			}
+		},
+		GotFirstResponseByte: func() {
+			timings.FirstByte = time.Since(timings.start)
+			if opts.Verbose >= VerboseConnection {
+				fmt.Fprintf(diag, "%s* Receiving response headers...%s\n", traceColor, resetColor)
+			}
+		},
+	}
+	traceCtx := httptrace.WithClientTrace(ctx, trace)
+	currentReq = currentReq.WithContext(traceCtx)
+
+	if opts.Verbose >= VerboseConnection && currentReq.TransferEncoding != nil {
+		fmt.Fprintf(diag, "%s* Sending the request body with chunked transfer encoding%s\n", traceColor, resetColor)
+	}
+	if opts.Verbose >= VerboseLines && opts.ResumeFrom > 0 {
+		fmt.Fprintf(diag, "%s* Resuming transfer from byte position %s%d%s\n", traceColor, valueColor, opts.ResumeFrom, resetColor)
+	}
+	if opts.Verbose >= VerboseHeaders {
+		printRequestVerbose(diag, "> ", currentReq, displayedRequestHeaders(currentReq.Header, opts), opts.Config)
+	} else if opts.Verbose >= VerboseLines {
+		printRequestLine(diag, "> ", currentReq, opts.Config)
+	}
+
+	var trc *tracer
+	if opts.Trace != nil {
+		trc = &tracer{w: opts.Trace}
+		trc.request(currentReq, displayedRequestHeaders(currentReq.Header, opts))
+		if currentReq.Body != nil && currentReq.Body != http.NoBody {
+			currentReq.Body = trc.body(currentReq.Body, "=> Send data")
+			// Bodies replayed for retries and redirects are traced too.
+			if getBody := currentReq.GetBody; getBody != nil {
+				currentReq.GetBody = func() (io.ReadCloser, error) {
+					body, err := getBody()
+					if err != nil {
+						return nil, err
 					}
+					return trc.body(body, "=> Send data"), nil
 				}
-				proto := ""
-				switch cs.Version {
-				case tls.VersionTLS10: proto = "TLSv1.0"
-				case tls.VersionTLS11: proto = "TLSv1.1"
-				case tls.VersionTLS12: proto = "TLSv1.2"
-				case tls.VersionTLS13: proto = "TLSv1.3"
-				default: proto = fmt.Sprintf("TLS Unknown (0x%x)", cs.Version)
-				}
-				fmt.Fprintf(os.Stderr, "%s* TLS handshake complete%s\n", traceColor, resetColor)
-				fmt.Fprintf(os.Stderr, "%s* Protocol: %s%s%s\n", traceColor, valueColor, proto, resetColor)
-				fmt.Fprintf(os.Stderr, "%s* Cipher Suite: %s%s%s\n", traceColor, valueColor, tls.CipherSuiteName(cs.CipherSuite), resetColor)
-				if len(cs.PeerCertificates) > 0 {
-					cert := cs.PeerCertificates[0]
-					fmt.Fprintf(os.Stderr, "%s* Server certificate:%s\n", traceColor, resetColor)
-					fmt.Fprintf(os.Stderr, "%s* Subject: %s%s%s\n", traceColor, valueColor, cert.Subject.String(), resetColor)
-					fmt.Fprintf(os.Stderr, "%s* Issuer: %s%s%s\n", traceColor, valueColor, cert.Issuer.String(), resetColor)
-					fmt.Fprintf(os.Stderr, "%s* Expiry: %s%s%s\n", traceColor, valueColor, cert.NotAfter.Format(time.RFC1123), resetColor)
-				}
-				if cs.NegotiatedProtocol != "" {
-					fmt.Fprintf(os.Stderr, "%s* ALPN: server accepted %s%s%s\n", traceColor, valueColor, cs.NegotiatedProtocol, resetColor)
-				}
+			}
+		}
+	}
 
-			},
-			GotConn: func(info httptrace.GotConnInfo) {
-				fmt.Fprintf(os.Stderr, "%s* Connection established to %s%s%s\n", traceColor, valueColor, info.Conn.RemoteAddr(), resetColor)
-			},
-			GotFirstResponseByte: func() {
-				fmt.Fprintf(os.Stderr, "%s* Receiving response headers...%s\n", traceColor, resetColor)
-			},
+	if currentReq.Body != nil && currentReq.Body != http.NoBody {
+		currentReq.Body = countingBody{currentReq.Body, &info.BytesSent}
+		if getBody := currentReq.GetBody; getBody != nil {
+			currentReq.GetBody = func() (io.ReadCloser, error) {
+				body, err := getBody()
+				if err != nil {
+					return nil, err
+				}
+				return countingBody{body, &info.BytesSent}, nil
+			}
 		}
-		traceCtx := httptrace.WithClientTrace(currentReq.Context(), trace)
-		currentReq = currentReq.WithContext(traceCtx)
 	}
 
-	if opts.Verbose {
-		fmt.Fprintf(os.Stderr, "> ")
-		fmt.Fprintf(os.Stderr, "%s%s%s ", keyColor, currentReq.Method, resetColor)
-		fmt.Fprintf(os.Stderr, "%s%s%s ", valueColor, currentReq.URL.RequestURI(), resetColor)
-		fmt.Fprintf(os.Stderr, "%s%s%s\n", valueColor, currentReq.Proto, resetColor)
+	timings.start = time.Now()
+	resp, err := client.Do(currentReq)
+	if err == nil && !got100 && opts.Verbose >= VerboseConnection && currentReq.Body != nil &&
+		strings.EqualFold(currentReq.Header.Get("Expect"), "100-continue") {
+		fmt.Fprintf(diag, "%s* No 100 Continue received%s\n", traceColor, resetColor)
+	}
+	for attempt := 1; attempt <= opts.Retries && ctx.Err() == nil; attempt++ {
+		reason := retryReason(resp, err, opts.RetryOnStatus)
+		if reason == "" {
+			break
+		}
+		if currentReq.Body != nil && currentReq.GetBody == nil {
+			if opts.Verbose >= VerboseLines {
+				fmt.Fprintf(diag, "%s* Not retrying: the request body cannot be replayed%s\n", warningColor, resetColor)
+			}
+			break
+		}
 
-		fmt.Fprintf(os.Stderr, "> ")
-		fmt.Fprintf(os.Stderr, "%s%s%s: ", keyColor, "Host", resetColor)
-		fmt.Fprintf(os.Stderr, "%s%s%s\n", valueColor, currentReq.Host, resetColor)
+		delay := retryDelay(opts.RetryDelay, attempt, resp)
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		if opts.Verbose >= VerboseLines {
+			fmt.Fprintf(diag, "%s* Retry attempt %d/%d in %s: %s%s\n", warningColor, attempt, opts.Retries, delay.Round(time.Millisecond), reason, resetColor)
+		}
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, fmt.Errorf("error performing request: %w", ctx.Err())
+		}
 
-		printHeadersVerboseColor(os.Stderr, '>', currentReq.Header, opts.Config)
-		fmt.Fprintf(os.Stderr, "> \n")
+		retryReq := currentReq.Clone(currentReq.Context())
+		if currentReq.GetBody != nil {
+			body, bodyErr := currentReq.GetBody()
+			if bodyErr != nil {
+				return nil, fmt.Errorf("error rewinding request body: %w", bodyErr)
+			}
+			retryReq.Body = body
+		}
+		resp, err = client.Do(retryReq)
 	}
+	timings.Total = time.Since(timings.start)
 
-	resp, err := client.Do(currentReq)
+	if opts.Verbose >= VerboseLines && resp != nil {
+		if opts.Verbose >= VerboseConnection {
+			fmt.Fprintf(diag, "%s* Using %s%s%s\n", traceColor, valueColor, resp.Proto, resetColor)
+		}
+		if resp.StatusCode == http.StatusPartialContent {
+			fmt.Fprintf(diag, "%s* Partial content: %s%s%s\n", traceColor, valueColor, resp.Header.Get("Content-Range"), resetColor)
+		}
+		if opts.ResumeFrom > 0 {
+			switch resp.StatusCode {
+			case http.StatusPartialContent:
+			case http.StatusRequestedRangeNotSatisfiable:
+				fmt.Fprintf(diag, "%s* Nothing to resume at byte %d; the download is already complete%s\n", traceColor, opts.ResumeFrom, resetColor)
+			default:
+				fmt.Fprintf(diag, "%s* Server ignored the resume range; restarting the download%s\n", warningColor, resetColor)
+			}
+		}
+		if opts.Verbose >= VerboseHeaders {
+			printResponseVerbose(diag, "< ", resp, opts.Config)
+		} else {
+			printStatusLine(diag, "< ", resp, opts.Config)
+		}
+	}
+	// The body of a 101 response is the upgraded connection, an
+	// io.ReadWriteCloser, so it is left unwrapped for the caller to write to.
+	upgraded := resp != nil && resp.StatusCode == http.StatusSwitchingProtocols
+	if resp != nil && !upgraded {
+		resp.Body = countingBody{resp.Body, &info.BytesReceived}
+	}
+	if trc != nil && resp != nil {
+		trc.response(resp)
+		if !upgraded {
+			resp.Body = trc.body(resp.Body, "<= Recv data")
+		}
+	}
+	if opts.Compressed && err == nil && !upgraded {
+		if err := decompressResponse(resp); err != nil {
+			return resp, err
+		}
+	}
+	if opts.MaxFileSize > 0 && err == nil && !upgraded {
+		if err := checkMaxFileSize(resp, opts.MaxFileSize); err != nil {
+			return resp, err
+		}
+	}
+	if opts.RateLimit > 0 && err == nil && !upgraded {
+		// Only the body is throttled; the headers have already arrived.
+		resp.Body = newRateLimitedBody(resp.Body, opts.RateLimit)
+		if opts.Verbose >= VerboseLines {
+			fmt.Fprintf(diag, "%s* Limiting download speed to %s%d bytes/s%s\n", traceColor, valueColor, opts.RateLimit, resetColor)
+		}
+	}
 
-	if opts.Verbose && resp != nil {
-		statusCodeColor := errorColor
-		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-			statusCodeColor = successColor
-		} else if resp.StatusCode >= 300 && resp.StatusCode < 400 {
-			statusCodeColor = warningColor
+	if err != nil {
+		if opts.Verbose >= VerboseLines {
+			fmt.Fprintf(diag, "%s* Request failed: %v%s\n", errorColor, err, resetColor)
 		}
+		return resp, fmt.Errorf("error performing request: %w", err)
+	}
+	if resp.Request != nil {
+		info.RequestHeaders = displayedRequestHeaders(resp.Request.Header, opts)
+	}
 
-		statusParts := strings.SplitN(resp.Status, " ", 2)
-		statusCodeStr := statusParts[0]
-		statusText := ""
-		if len(statusParts) > 1 {
-			statusText = statusParts[1]
+	if opts.CookieJarFile != "" {
+		if err := jar.Save(opts.CookieJarFile); err != nil {
+			return resp, err
+		}
+		if opts.Verbose >= VerboseLines {
+			fmt.Fprintf(diag, "%s* Saved cookies to %s%s%s\n", traceColor, valueColor, opts.CookieJarFile, resetColor)
 		}
+	}
+
+	return resp, nil
+}
 
-		fmt.Fprintf(os.Stderr, "< ")
-		fmt.Fprintf(os.Stderr, "%s%s%s ", valueColor, resp.Proto, resetColor)
-		fmt.Fprintf(os.Stderr, "%s%s%s ", statusCodeColor, statusCodeStr, resetColor)
-		fmt.Fprintf(os.Stderr, "%s%s%s\n", valueColor, statusText, resetColor)
+// sameHost reports whether a and b name the same host and port, taking the
+// scheme's default port into account.
+func sameHost(a, b *url.URL) bool {
+	return strings.EqualFold(a.Hostname(), b.Hostname()) && effectivePort(a) == effectivePort(b)
+}
+
+// effectivePort returns the port of u, or its scheme's default port.
+func effectivePort(u *url.URL) string {
+	if port := u.Port(); port != "" {
+		return port
+	}
+	if u.Scheme == "https" {
+		return "443"
+	}
+	return "80"
+}
 
-		printHeadersVerboseColor(os.Stderr, '<', resp.Header, opts.Config)
-		fmt.Fprintf(os.Stderr, "< \n")
+// diagnostics returns the writer for verbose output.
+func diagnostics(opts RequestOptions) io.Writer {
+	if opts.Diagnostics != nil {
+		return opts.Diagnostics
 	}
+	return os.Stderr
+}
 
+// tlsVersions maps --tls-min/--tls-max values to crypto/tls constants.
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// ParseTLSVersion converts a version string such as "1.2" into the matching
+// tls.VersionTLS* constant. An empty string returns 0 (no constraint).
+func ParseTLSVersion(version string) (uint16, error) {
+	if version == "" {
+		return 0, nil
+	}
+	v, ok := tlsVersions[strings.TrimPrefix(version, "v")]
+	if !ok {
+		return 0, fmt.Errorf("invalid TLS version %q (use 1.0, 1.1, 1.2 or 1.3)", version)
+	}
+	return v, nil
+}
+
+// loadClientCertificate loads a client certificate and its private key.
+// When keyFile is empty, both are expected in certFile as a combined PEM.
+func loadClientCertificate(certFile, keyFile string) (tls.Certificate, error) {
+	if keyFile == "" {
+		keyFile = certFile
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
 	if err != nil {
-		if opts.Verbose {
-			fmt.Fprintf(os.Stderr, "%s* Request failed: %v%s\n", errorColor, err, resetColor)
+		return tls.Certificate{}, fmt.Errorf("could not load client certificate %s with key %s: %w", certFile, keyFile, err)
+	}
+	if cert.Leaf == nil {
+		if leaf, err := x509.ParseCertificate(cert.Certificate[0]); err == nil {
+			cert.Leaf = leaf
 		}
-		return resp, fmt.Errorf("error performing request: %w", err)
 	}
+	return cert, nil
+}
 
-	return resp, nil
+// parseProxyURL validates a --proxy value. Like curl, a proxy without a
+// scheme is assumed to be an HTTP proxy. An empty value returns nil.
+func parseProxyURL(proxy string) (*url.URL, error) {
+	if proxy == "" {
+		return nil, nil
+	}
+	if !strings.Contains(proxy, "://") {
+		proxy = "http://" + proxy
+	}
+	u, err := url.Parse(proxy)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL %q: %w", proxy, err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q (use http, https, socks5 or socks5h)", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL %q: missing host", proxy)
+	}
+	return u, nil
+}
+
+// applyCustomHeaders adds headers given in "Key: Value" (or "Key;" for an
+// empty value) format. The first custom value for a key replaces any value
+// hurl set implicitly, so the same header is never sent twice by accident;
+// repeated custom keys are all sent. "Key:" with nothing after the colon
+// removes the header instead, like curl, so implicit headers such as
+// User-Agent can be left out.
+func applyCustomHeaders(header http.Header, custom []string) {
+	seen := make(map[string]bool)
+	add := func(key, value string) {
+		canonical := http.CanonicalHeaderKey(key)
+		if !seen[canonical] {
+			header.Del(canonical)
+			seen[canonical] = true
+		}
+		header.Add(canonical, value)
+	}
+	remove := func(key string) {
+		canonical := http.CanonicalHeaderKey(key)
+		seen[canonical] = true
+		header.Del(canonical)
+		if canonical == "User-Agent" {
+			// As with OmitUserAgent, an empty value keeps net/http from
+			// adding its own.
+			header.Set(canonical, "")
+		}
+	}
+
+	for _, h := range custom {
+		parts := strings.SplitN(h, ":", 2)
+		if len(parts) == 2 {
+			key := strings.TrimSpace(parts[0])
+			value := strings.TrimSpace(parts[1])
+			if key == "" {
+				continue
+			}
+			if value == "" {
+				remove(key)
+			} else {
+				add(key, value)
+			}
+		} else if len(parts) == 1 && strings.TrimSpace(parts[0]) != "" {
+			// Handle headers with empty value, like "X-Custom-Flag;"
+			key := strings.TrimRight(strings.TrimSpace(parts[0]), ";")
+			add(key, "")
+		}
+	}
+}
+
+// mergeDefaultHeaders combines the config file's default headers with the
+// -H headers. A default is dropped when -H names the same key, and "Key:"
+// with an empty value removes a default without sending anything.
+func mergeDefaultHeaders(defaults, custom []string) []string {
+	if len(defaults) == 0 {
+		return custom
+	}
+	headerKey := func(h string) string {
+		key, _, _ := strings.Cut(h, ":")
+		return http.CanonicalHeaderKey(strings.TrimRight(strings.TrimSpace(key), ";"))
+	}
+
+	isDefault := make(map[string]bool)
+	for _, h := range defaults {
+		isDefault[headerKey(h)] = true
+	}
+	overridden := make(map[string]bool)
+	var merged, rest []string
+	for _, h := range custom {
+		key := headerKey(h)
+		overridden[key] = true
+		if _, v, ok := strings.Cut(h, ":"); ok && strings.TrimSpace(v) == "" && isDefault[key] {
+			continue // Removes the default
+		}
+		rest = append(rest, h)
+	}
+	for _, h := range defaults {
+		if !overridden[headerKey(h)] {
+			merged = append(merged, h)
+		}
+	}
+	return append(merged, rest...)
+}
+
+// displayedRequestHeaders returns the outgoing headers as they should be
+// echoed in verbose output: the --bearer token is hidden so output can be
+// shared without leaking credentials, and a suppressed (empty) User-Agent,
+// which is never sent, is left out.
+func displayedRequestHeaders(header http.Header, opts RequestOptions) http.Header {
+	shown := header.Clone()
+	if ua, ok := shown["User-Agent"]; ok && len(ua) == 1 && ua[0] == "" {
+		delete(shown, "User-Agent")
+	}
+	if opts.BearerToken != "" {
+		for i, v := range shown["Authorization"] {
+			if v == "Bearer "+opts.BearerToken {
+				shown["Authorization"][i] = "Bearer [REDACTED]"
+			}
+		}
+	}
+	return shown
 }
 
 // printHeadersVerboseColor prints headers to the specified writer with a prefix and colors.
-func printHeadersVerboseColor(w io.Writer, prefix rune, headers http.Header, cfg config.Config) {
-	keyColor := config.GetAnsiCode(cfg.HeaderKeyColor)
-	valueColor := config.GetAnsiCode(cfg.HeaderValueColor)
-	resetColor := config.ColorReset
+func printHeadersVerboseColor(w io.Writer, prefix string, headers http.Header, cfg config.Config) {
+	keyColor := cfg.GetAnsiCode(cfg.HeaderKeyColor)
+	resetColor := cfg.ResetCode()
 
 	keys := make([]string, 0, len(headers))
 	for k := range headers {
@@ -230,10 +879,72 @@ func printHeadersVerboseColor(w io.Writer, prefix rune, headers http.Header, cfg
 
 	for _, k := range keys {
 		values := headers[k]
+		valueColor := cfg.HeaderValueCode(k)
 		for _, v := range values {
-			fmt.Fprintf(w, "%c ", prefix) // Print prefix plainly
+			fmt.Fprint(w, prefix) // Print prefix plainly
 			fmt.Fprintf(w, "%s%s%s: ", keyColor, k, resetColor)
 			fmt.Fprintf(w, "%s%s%s\n", valueColor, v, resetColor)
 		}
 	}
-}
\ No newline at end of file
+}
+
+// printRequestVerbose prints the request line, Host and the given headers,
+// each line starting with prefix, followed by a blank prefixed line.
+func printRequestVerbose(w io.Writer, prefix string, req *http.Request, headers http.Header, cfg config.Config) {
+	keyColor := cfg.GetAnsiCode(cfg.HeaderKeyColor)
+	valueColor := cfg.GetAnsiCode(cfg.HeaderValueColor)
+	resetColor := cfg.ResetCode()
+
+	printRequestLine(w, prefix, req, cfg)
+
+	fmt.Fprint(w, prefix)
+	fmt.Fprintf(w, "%s%s%s: ", keyColor, "Host", resetColor)
+	fmt.Fprintf(w, "%s%s%s\n", valueColor, req.Host, resetColor)
+
+	printHeadersVerboseColor(w, prefix, headers, cfg)
+	fmt.Fprintf(w, "%s\n", prefix)
+}
+
+// printRequestLine prints the request line of req, starting with prefix.
+func printRequestLine(w io.Writer, prefix string, req *http.Request, cfg config.Config) {
+	keyColor := cfg.GetAnsiCode(cfg.HeaderKeyColor)
+	valueColor := cfg.GetAnsiCode(cfg.HeaderValueColor)
+	resetColor := cfg.ResetCode()
+
+	fmt.Fprint(w, prefix)
+	fmt.Fprintf(w, "%s%s%s ", keyColor, req.Method, resetColor)
+	fmt.Fprintf(w, "%s%s%s ", valueColor, req.URL.RequestURI(), resetColor)
+	fmt.Fprintf(w, "%s%s%s\n", valueColor, req.Proto, resetColor)
+}
+
+// printResponseVerbose prints the status line and headers of resp, each line
+// starting with prefix, followed by a blank prefixed line.
+func printResponseVerbose(w io.Writer, prefix string, resp *http.Response, cfg config.Config) {
+	printStatusLine(w, prefix, resp, cfg)
+	printHeadersVerboseColor(w, prefix, resp.Header, cfg)
+	fmt.Fprintf(w, "%s\n", prefix)
+}
+
+// printStatusLine prints the status line of resp, starting with prefix.
+func printStatusLine(w io.Writer, prefix string, resp *http.Response, cfg config.Config) {
+	valueColor := cfg.GetAnsiCode(cfg.HeaderValueColor)
+	resetColor := cfg.ResetCode()
+	statusCode, statusText, _ := strings.Cut(resp.Status, " ")
+
+	fmt.Fprint(w, prefix)
+	fmt.Fprintf(w, "%s%s%s ", valueColor, resp.Proto, resetColor)
+	fmt.Fprintf(w, "%s%s%s ", cfg.StatusColor(resp.StatusCode), statusCode, resetColor)
+	fmt.Fprintf(w, "%s%s%s\n", valueColor, statusText, resetColor)
+}
+
+// addrFamily names the address family of an ip:port address.
+func addrFamily(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.To4() == nil {
+		return "IPv6"
+	}
+	return "IPv4"
+}
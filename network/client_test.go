@@ -0,0 +1,166 @@
+package network
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestApplyCustomHeaders(t *testing.T) {
+	tests := []struct {
+		name   string
+		custom []string
+		want   http.Header
+	}{
+		{
+			name:   "replaces default",
+			custom: []string{"Accept: text/plain"},
+			want:   http.Header{"Accept": {"text/plain"}, "User-Agent": {"hurl"}},
+		},
+		{
+			name:   "repeated keys are all sent",
+			custom: []string{"x-tag: a", "X-Tag: b"},
+			want:   http.Header{"Accept": {"*/*"}, "User-Agent": {"hurl"}, "X-Tag": {"a", "b"}},
+		},
+		{
+			name:   "colon with no value removes default",
+			custom: []string{"Accept:"},
+			want:   http.Header{"User-Agent": {"hurl"}},
+		},
+		{
+			name:   "semicolon sends empty value",
+			custom: []string{"X-Flag;"},
+			want:   http.Header{"Accept": {"*/*"}, "User-Agent": {"hurl"}, "X-Flag": {""}},
+		},
+		{
+			name:   "semicolon replaces default with empty value",
+			custom: []string{"accept;"},
+			want:   http.Header{"Accept": {""}, "User-Agent": {"hurl"}},
+		},
+		{
+			name:   "removing User-Agent leaves empty value",
+			custom: []string{"User-Agent:"},
+			want:   http.Header{"Accept": {"*/*"}, "User-Agent": {""}},
+		},
+		{
+			name:   "value after removal is sent",
+			custom: []string{"Accept:", "Accept: text/html"},
+			want:   http.Header{"Accept": {"text/html"}, "User-Agent": {"hurl"}},
+		},
+		{
+			name:   "blank key ignored",
+			custom: []string{": value", "   "},
+			want:   http.Header{"Accept": {"*/*"}, "User-Agent": {"hurl"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{"Accept": {"*/*"}, "User-Agent": {"hurl"}}
+			applyCustomHeaders(header, tt.custom)
+			if !reflect.DeepEqual(header, tt.want) {
+				t.Errorf("header = %v, want %v", header, tt.want)
+			}
+		})
+	}
+}
+
+func TestDoSendsCustomHeaders(t *testing.T) {
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+	defer srv.Close()
+
+	result, err := Do(RequestOptions{
+		URL:           srv.URL,
+		Accept:        "application/json",
+		CustomHeaders: []string{"Accept:", "User-Agent:", "X-Flag;"},
+	})
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	result.Response.Body.Close()
+
+	if v, ok := got["Accept"]; ok {
+		t.Errorf("Accept = %q, want it removed", v)
+	}
+	if v, ok := got["User-Agent"]; ok {
+		t.Errorf("User-Agent = %q, want none rather than Go's default", v)
+	}
+	if v, ok := got["X-Flag"]; !ok || len(v) != 1 || v[0] != "" {
+		t.Errorf("X-Flag = %q, want a single empty value", v)
+	}
+}
+
+// TestDoExpectContinueTimeout checks that the body is sent once the wait for
+// 100 Continue times out, against a server that never sends one.
+func TestDoExpectContinueTimeout(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	const timeout = 100 * time.Millisecond
+	type received struct {
+		body    string
+		elapsed time.Duration
+		err     error
+	}
+	done := make(chan received, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			done <- received{err: err}
+			return
+		}
+		defer conn.Close()
+		br := bufio.NewReader(conn)
+		req, err := http.ReadRequest(br)
+		if err != nil {
+			done <- received{err: err}
+			return
+		}
+		start := time.Now()
+		body, err := io.ReadAll(req.Body)
+		done <- received{string(body), time.Since(start), err}
+		io.WriteString(conn, "HTTP/1.1 200 OK\r\nContent-Length: 0\r\nConnection: close\r\n\r\n")
+	}()
+
+	var diag strings.Builder
+	result, err := Do(RequestOptions{
+		URL:           "http://" + ln.Addr().String() + "/upload",
+		Method:        http.MethodPost,
+		Body:          strings.NewReader("payload"),
+		CustomHeaders: []string{"Expect: 100-continue"},
+		ExpectTimeout: timeout,
+		Verbose:       VerboseConnection,
+		Diagnostics:   &diag,
+	})
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	result.Response.Body.Close()
+
+	got := <-done
+	if got.err != nil {
+		t.Fatalf("server: %v", got.err)
+	}
+	if got.body != "payload" {
+		t.Errorf("server received body %q, want %q", got.body, "payload")
+	}
+	if got.elapsed < timeout/2 {
+		t.Errorf("body arrived after %v, want the client to wait about %v", got.elapsed, timeout)
+	}
+	for _, line := range []string{"* Waiting for 100 Continue before sending the body...", "* No 100 Continue received"} {
+		if !strings.Contains(diag.String(), line) {
+			t.Errorf("verbose output lacks %q:\n%s", line, diag.String())
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package network
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// ConnectTo is a --connect-to rule: connections meant for Host:Port go to
+// ConnectHost:ConnectPort instead. An empty Host or Port matches any, and
+// an empty ConnectHost or ConnectPort keeps the original one. The URL,
+// and so the Host header and the TLS server name, are not changed.
+type ConnectTo struct {
+	Host        string
+	Port        string
+	ConnectHost string
+	ConnectPort string
+}
+
+// ParseConnectTo parses a rule given as HOST:PORT:CONNECT_HOST:CONNECT_PORT,
+// where IPv6 addresses are written in brackets, e.g.
+// "example.com:443:[::1]:8443".
+func ParseConnectTo(value string) (ConnectTo, error) {
+	var parts []string
+	rest := value
+	for len(parts) < 3 {
+		part, tail, err := cutConnectToPart(rest)
+		if err != nil {
+			return ConnectTo{}, fmt.Errorf("invalid --connect-to %q: %v", value, err)
+		}
+		parts = append(parts, part)
+		rest = tail
+	}
+	if strings.HasPrefix(rest, "[") {
+		if !strings.HasSuffix(rest, "]") {
+			return ConnectTo{}, fmt.Errorf("invalid --connect-to %q: unmatched '['", value)
+		}
+	} else if strings.Contains(rest, ":") {
+		return ConnectTo{}, fmt.Errorf("invalid --connect-to %q (expected HOST:PORT:CONNECT_HOST:CONNECT_PORT)", value)
+	}
+	parts = append(parts, rest)
+	return ConnectTo{
+		Host:        strings.Trim(parts[0], "[]"),
+		Port:        parts[1],
+		ConnectHost: strings.Trim(parts[2], "[]"),
+		ConnectPort: parts[3],
+	}, nil
+}
+
+// cutConnectToPart splits off the part of s before the next ':' that is
+// not inside brackets.
+func cutConnectToPart(s string) (part, rest string, err error) {
+	start := 0
+	if strings.HasPrefix(s, "[") {
+		end := strings.IndexByte(s, ']')
+		if end < 0 {
+			return "", "", fmt.Errorf("unmatched '['")
+		}
+		start = end
+	}
+	i := strings.IndexByte(s[start:], ':')
+	if i < 0 {
+		return "", "", fmt.Errorf("expected HOST:PORT:CONNECT_HOST:CONNECT_PORT")
+	}
+	return s[:start+i], s[start+i+1:], nil
+}
+
+// connectTarget returns the address to dial for addr (host:port) under
+// the first matching rule, or addr itself if none matches.
+func connectTarget(rules []ConnectTo, addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	for _, r := range rules {
+		if (r.Host == "" || strings.EqualFold(r.Host, host)) && (r.Port == "" || r.Port == port) {
+			if r.ConnectHost != "" {
+				host = r.ConnectHost
+			}
+			if r.ConnectPort != "" {
+				port = r.ConnectPort
+			}
+			return net.JoinHostPort(host, port)
+		}
+	}
+	return addr
+}
@@ -0,0 +1,288 @@
+package network
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// httpOnlyPrefix marks HttpOnly cookies in the Netscape cookie-file format.
+const httpOnlyPrefix = "#HttpOnly_"
+
+// cookieEntry is one line of a Netscape cookie file.
+type cookieEntry struct {
+	Domain            string    // Domain as written in the file, possibly with a leading dot
+	IncludeSubdomains bool      // TRUE if the cookie is sent to subdomains as well
+	Path              string    // Path the cookie applies to
+	Secure            bool      // TRUE if the cookie is only sent over HTTPS
+	HttpOnly          bool      // Recorded via the #HttpOnly_ domain prefix
+	Expires           time.Time // Zero for session cookies
+	Name              string
+	Value             string
+}
+
+// host returns the domain without the leading dot used for subdomain cookies.
+func (e cookieEntry) host() string {
+	return strings.TrimPrefix(e.Domain, ".")
+}
+
+// key identifies a cookie the same way the jar does: by domain, path and name.
+func (e cookieEntry) key() string {
+	return strings.ToLower(e.host()) + ";" + e.Path + ";" + e.Name
+}
+
+// CookieJar is an http.CookieJar backed by net/http/cookiejar that also keeps
+// every cookie's attributes, so it can be saved and re-loaded losslessly in
+// the Netscape cookie-file format used by curl.
+type CookieJar struct {
+	jar     *cookiejar.Jar
+	mu      sync.Mutex
+	entries []cookieEntry
+}
+
+// NewCookieJar returns an empty cookie jar.
+func NewCookieJar() (*CookieJar, error) {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not create cookie jar: %w", err)
+	}
+	return &CookieJar{jar: jar}, nil
+}
+
+// Cookies implements http.CookieJar.
+func (j *CookieJar) Cookies(u *url.URL) []*http.Cookie {
+	return j.jar.Cookies(u)
+}
+
+// SetCookies implements http.CookieJar, recording the attributes of each
+// cookie the underlying jar accepts.
+func (j *CookieJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.jar.SetCookies(u, cookies)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	now := time.Now()
+	for _, c := range cookies {
+		entry := cookieEntry{
+			Domain:   u.Hostname(),
+			Path:     c.Path,
+			Secure:   c.Secure,
+			HttpOnly: c.HttpOnly,
+			Name:     c.Name,
+			Value:    c.Value,
+		}
+		if c.Domain != "" {
+			entry.Domain = "." + strings.TrimPrefix(c.Domain, ".")
+			entry.IncludeSubdomains = true
+		}
+		if entry.Path == "" || !strings.HasPrefix(entry.Path, "/") {
+			entry.Path = defaultCookiePath(u.Path)
+		}
+
+		removed := false
+		switch {
+		case c.MaxAge < 0:
+			removed = true
+		case c.MaxAge > 0:
+			entry.Expires = now.Add(time.Duration(c.MaxAge) * time.Second)
+		case !c.Expires.IsZero():
+			entry.Expires = c.Expires
+			removed = !c.Expires.After(now)
+		}
+
+		if removed {
+			j.remove(entry.key())
+			continue
+		}
+		// Only remember cookies the jar actually accepted.
+		if !j.accepted(u, c, entry) {
+			continue
+		}
+		j.store(entry)
+	}
+}
+
+// Load reads cookies from a Netscape cookie file into the jar.
+// Expired cookies are skipped.
+func (j *CookieJar) Load(filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var entries []cookieEntry
+	scanner := bufio.NewScanner(f)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimRight(scanner.Text(), "\r")
+		httpOnly := false
+		if strings.HasPrefix(line, httpOnlyPrefix) {
+			httpOnly = true
+			line = strings.TrimPrefix(line, httpOnlyPrefix)
+		} else if strings.HasPrefix(line, "#") || strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) == 6 {
+			// Cookies with an empty value may lose their trailing tab.
+			fields = append(fields, "")
+		}
+		if len(fields) != 7 {
+			return fmt.Errorf("%s:%d: expected 7 tab-separated fields, got %d", filename, lineNum, len(fields))
+		}
+		expiry, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			return fmt.Errorf("%s:%d: invalid expiry %q", filename, lineNum, fields[4])
+		}
+
+		entry := cookieEntry{
+			Domain:            fields[0],
+			IncludeSubdomains: strings.EqualFold(fields[1], "TRUE"),
+			Path:              fields[2],
+			Secure:            strings.EqualFold(fields[3], "TRUE"),
+			HttpOnly:          httpOnly,
+			Name:              fields[5],
+			Value:             fields[6],
+		}
+		if expiry > 0 {
+			entry.Expires = time.Unix(expiry, 0)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("could not read cookie file %s: %w", filename, err)
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	now := time.Now()
+	for _, entry := range entries {
+		if !entry.Expires.IsZero() && !entry.Expires.After(now) {
+			continue
+		}
+		cookie := &http.Cookie{
+			Name:     entry.Name,
+			Value:    entry.Value,
+			Path:     entry.Path,
+			Secure:   entry.Secure,
+			HttpOnly: entry.HttpOnly,
+			Expires:  entry.Expires,
+		}
+		if entry.IncludeSubdomains {
+			cookie.Domain = entry.host()
+		}
+		j.jar.SetCookies(entry.url(), []*http.Cookie{cookie})
+		j.store(entry)
+	}
+	return nil
+}
+
+// Save writes all cookies in the jar to a Netscape cookie file, replacing it.
+func (j *CookieJar) Save(filename string) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	var b strings.Builder
+	b.WriteString("# Netscape HTTP Cookie File\n")
+	b.WriteString("# This file was generated by hurl. Edit at your own risk.\n\n")
+	now := time.Now()
+	for _, e := range j.entries {
+		if !e.Expires.IsZero() && !e.Expires.After(now) {
+			continue
+		}
+		domain := e.Domain
+		if e.HttpOnly {
+			domain = httpOnlyPrefix + domain
+		}
+		var expiry int64
+		if !e.Expires.IsZero() {
+			expiry = e.Expires.Unix()
+		}
+		fmt.Fprintf(&b, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n",
+			domain, netscapeBool(e.IncludeSubdomains), e.Path, netscapeBool(e.Secure), expiry, e.Name, e.Value)
+	}
+
+	if err := os.WriteFile(filename, []byte(b.String()), 0600); err != nil {
+		return fmt.Errorf("could not write cookie jar %s: %w", filename, err)
+	}
+	return nil
+}
+
+// url builds a URL the cookie would be sent to, for feeding it to the jar.
+func (e cookieEntry) url() *url.URL {
+	scheme := "http"
+	if e.Secure {
+		scheme = "https"
+	}
+	return &url.URL{Scheme: scheme, Host: e.host(), Path: e.Path}
+}
+
+// accepted reports whether the underlying jar accepts cookie, sent by u, at
+// entry's domain and path. Asking j.jar itself is not enough: a cookie with
+// the same name and value at a parent path would match any rejected one.
+func (j *CookieJar) accepted(u *url.URL, cookie *http.Cookie, entry cookieEntry) bool {
+	probe, err := cookiejar.New(nil)
+	if err != nil {
+		return false
+	}
+	probe.SetCookies(u, []*http.Cookie{cookie})
+	for _, c := range probe.Cookies(entry.url()) {
+		if c.Name == entry.Name && c.Value == entry.Value {
+			return true
+		}
+	}
+	return false
+}
+
+// store adds or replaces an entry, keeping the original order. Callers hold j.mu.
+func (j *CookieJar) store(entry cookieEntry) {
+	key := entry.key()
+	for i := range j.entries {
+		if j.entries[i].key() == key {
+			j.entries[i] = entry
+			return
+		}
+	}
+	j.entries = append(j.entries, entry)
+}
+
+// remove deletes the entry with the given key. Callers hold j.mu.
+func (j *CookieJar) remove(key string) {
+	for i := range j.entries {
+		if j.entries[i].key() == key {
+			j.entries = append(j.entries[:i], j.entries[i+1:]...)
+			return
+		}
+	}
+}
+
+// defaultCookiePath computes the RFC 6265 default path for a request path.
+func defaultCookiePath(requestPath string) string {
+	if requestPath == "" || requestPath[0] != '/' {
+		return "/"
+	}
+	dir := path.Dir(requestPath)
+	if dir == "." {
+		return "/"
+	}
+	return dir
+}
+
+// netscapeBool formats a boolean the way Netscape cookie files expect.
+func netscapeBool(v bool) string {
+	if v {
+		return "TRUE"
+	}
+	return "FALSE"
+}
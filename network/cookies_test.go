@@ -0,0 +1,126 @@
+package network
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const cookieFileHeader = "# Netscape HTTP Cookie File\n# This file was generated by hurl. Edit at your own risk.\n\n"
+
+func TestCookieJarRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.txt")
+	fixture := cookieFileHeader +
+		".example.com\tTRUE\t/\tFALSE\t4102444800\tsub\t1\n" +
+		"#HttpOnly_example.com\tFALSE\t/app\tTRUE\t4102444800\tsid\tabc\n" +
+		"example.com\tFALSE\t/\tFALSE\t0\tsession\ts\n" +
+		"example.com\tFALSE\t/\tFALSE\t4102444800\tempty\n" +
+		"example.com\tFALSE\t/\tFALSE\t1\texpired\tx\n"
+	if err := os.WriteFile(in, []byte(fixture), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	jar, err := NewCookieJar()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := jar.Load(in); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	out := filepath.Join(dir, "out.txt")
+	if err := jar.Save(out); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	saved, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := strings.Split(cookieFileHeader+
+		".example.com\tTRUE\t/\tFALSE\t4102444800\tsub\t1\n"+
+		"#HttpOnly_example.com\tFALSE\t/app\tTRUE\t4102444800\tsid\tabc\n"+
+		"example.com\tFALSE\t/\tFALSE\t0\tsession\ts\n"+
+		"example.com\tFALSE\t/\tFALSE\t4102444800\tempty\t\n", "\n")
+	got := strings.Split(string(saved), "\n")
+	if len(got) != len(want) {
+		t.Fatalf("saved %d lines, want %d:\n%s", len(got), len(want), saved)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("line %d = %q, want %q", i+1, got[i], want[i])
+		}
+	}
+
+	// The loaded cookies are also sent.
+	u, _ := url.Parse("https://www.example.com/app/x")
+	var names []string
+	for _, c := range jar.Cookies(u) {
+		names = append(names, c.Name)
+	}
+	if strings.Join(names, ",") != "sub" {
+		t.Errorf("cookies for %s = %v, want [sub]", u, names)
+	}
+	u, _ = url.Parse("https://example.com/app/x")
+	names = nil
+	for _, c := range jar.Cookies(u) {
+		names = append(names, c.Name)
+	}
+	if len(names) != 4 {
+		t.Errorf("cookies for %s = %v, want sid, sub, session and empty", u, names)
+	}
+}
+
+func TestCookieJarLoadErrors(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"example.com\tFALSE\t/\n", "expected 7 tab-separated fields, got 3"},
+		{"example.com\tFALSE\t/\tFALSE\tsoon\tname\tvalue\n", `invalid expiry "soon"`},
+	}
+	for _, tt := range tests {
+		file := filepath.Join(t.TempDir(), "cookies.txt")
+		if err := os.WriteFile(file, []byte(tt.line), 0600); err != nil {
+			t.Fatal(err)
+		}
+		jar, _ := NewCookieJar()
+		if err := jar.Load(file); err == nil || !strings.Contains(err.Error(), file+":1: "+tt.want) {
+			t.Errorf("Load(%q) error = %v, want %q", tt.line, err, tt.want)
+		}
+	}
+}
+
+// TestCookieJarSkipsRejectedCookie checks that a cookie the jar rejects is not
+// saved just because a cookie with the same name and value exists at a parent
+// path.
+func TestCookieJarSkipsRejectedCookie(t *testing.T) {
+	jar, err := NewCookieJar()
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse("http://example.com/app/page")
+	jar.SetCookies(u, []*http.Cookie{{Name: "id", Value: "1", Path: "/", Domain: "example.com"}})
+	// www.example.com does not domain-match the sending host, so the jar
+	// rejects this one, although id=1 is sent to www.example.com/app.
+	jar.SetCookies(u, []*http.Cookie{{Name: "id", Value: "1", Path: "/app", Domain: "www.example.com"}})
+	jar.SetCookies(u, []*http.Cookie{{Name: "id", Value: "1", Path: "/app"}})
+
+	file := filepath.Join(t.TempDir(), "cookies.txt")
+	if err := jar.Save(file); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	saved, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := cookieFileHeader +
+		".example.com\tTRUE\t/\tFALSE\t0\tid\t1\n" +
+		"example.com\tFALSE\t/app\tFALSE\t0\tid\t1\n"
+	if string(saved) != want {
+		t.Errorf("saved:\n%s\nwant:\n%s", saved, want)
+	}
+}
@@ -0,0 +1,455 @@
+package network
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxDNSMessage is the largest DNS message the resolver accepts.
+const maxDNSMessage = 65535
+
+// dnsQueryTypes names the query types shown in verbose output.
+var dnsQueryTypes = map[uint16]string{1: "A", 5: "CNAME", 28: "AAAA"}
+
+// dialTraceKey is the context key under which FetchContext passes the
+// verbose settings of the current request to the resolver and dialer,
+// which are shared by all requests.
+type dialTraceKey struct{}
+
+// dialTrace writes the resolver's and dialer's verbose lines for one
+// request.
+type dialTrace struct {
+	w       io.Writer
+	verbose int
+	color   string
+	reset   string
+}
+
+// traceDial writes a verbose line for the request ctx belongs to, if any.
+func traceDial(ctx context.Context, format string, args ...any) {
+	t, ok := ctx.Value(dialTraceKey{}).(dialTrace)
+	if !ok || t.verbose < VerboseConnection {
+		return
+	}
+	fmt.Fprintf(t.w, "%s* %s%s\n", t.color, fmt.Sprintf(format, args...), t.reset)
+}
+
+// dnsCacheTTL is how long hostCache keeps the addresses of a host. The
+// system resolver does not report TTLs, so, like curl, a fixed time is used.
+const dnsCacheTTL = 60 * time.Second
+
+// hostCache caches the addresses the system resolver returns for each host
+// name. It is shared by every request that uses the same transport, so it
+// is safe for concurrent use.
+type hostCache struct {
+	upstream *net.Resolver
+
+	mu      sync.Mutex
+	entries map[string]*hostCacheEntry
+}
+
+// hostCacheEntry is the cached addresses of a host and when they expire.
+// done is closed once the lookup has finished, so that the A and AAAA
+// queries Go's resolver sends together share one system lookup.
+type hostCacheEntry struct {
+	done    chan struct{}
+	addrs   []net.IPAddr
+	err     error
+	expires time.Time
+}
+
+// newHostCache returns an empty cache in front of net.DefaultResolver.
+func newHostCache() *hostCache {
+	return &hostCache{upstream: net.DefaultResolver, entries: make(map[string]*hostCacheEntry)}
+}
+
+// resolver returns a net.Resolver that answers from the cache, so that a
+// net.Dialer using it still races the address families of a host (RFC
+// 8305) as set by its FallbackDelay.
+func (c *hostCache) resolver() *net.Resolver {
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			return &dnsConn{ctx: ctx, exchange: c.exchange}, nil
+		},
+	}
+}
+
+// lookup returns the addresses of host, from the cache while they are
+// fresh and from the system resolver otherwise. Failed lookups are not
+// cached.
+func (c *hostCache) lookup(ctx context.Context, host string) ([]net.IPAddr, error) {
+	key := strings.ToLower(host)
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	if ok {
+		c.mu.Unlock()
+		select {
+		case <-entry.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if entry.err != nil {
+			return nil, entry.err // The lookup this one waited for failed
+		}
+		if time.Now().Before(entry.expires) {
+			traceDial(ctx, "DNS cache hit for %s, expires in %s", host, time.Until(entry.expires).Round(time.Second))
+			return entry.addrs, nil
+		}
+		c.mu.Lock()
+		if c.entries[key] == entry {
+			delete(c.entries, key)
+		}
+	}
+	entry = &hostCacheEntry{done: make(chan struct{})}
+	c.entries[key] = entry
+	c.mu.Unlock()
+
+	// As with DoH, the system lookup must not be reported by the request's
+	// own trace callbacks.
+	upCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if deadline, ok := ctx.Deadline(); ok {
+		upCtx, cancel = context.WithDeadline(upCtx, deadline)
+		defer cancel()
+	}
+	defer context.AfterFunc(ctx, cancel)()
+
+	entry.addrs, entry.err = c.upstream.LookupIPAddr(upCtx, host)
+	entry.expires = time.Now().Add(dnsCacheTTL)
+	close(entry.done)
+	if entry.err != nil {
+		c.mu.Lock()
+		if c.entries[key] == entry {
+			delete(c.entries, key)
+		}
+		c.mu.Unlock()
+		return nil, entry.err
+	}
+	traceDial(ctx, "DNS cache miss for %s: %d address(es), cached for %s", host, len(entry.addrs), dnsCacheTTL)
+	return entry.addrs, nil
+}
+
+// exchange answers an A or AAAA query with the cached addresses of its
+// name, and any other query with no records.
+func (c *hostCache) exchange(ctx context.Context, query []byte) ([]byte, error) {
+	_, name, qtype, err := dnsQuestionKey(query)
+	if err != nil {
+		return nil, err
+	}
+	addrs, err := c.lookup(ctx, name)
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		return hostResponse(query, nil, true), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var ips []net.IP
+	for _, a := range addrs {
+		ip4 := a.IP.To4()
+		switch {
+		case qtype == "A" && ip4 != nil:
+			ips = append(ips, ip4)
+		case qtype == "AAAA" && ip4 == nil && len(a.IP) == net.IPv6len:
+			ips = append(ips, a.IP)
+		}
+	}
+	return hostResponse(query, ips, false), nil
+}
+
+// hostResponse builds the response to query with an A or AAAA record for
+// each of ips, or an NXDOMAIN response if notFound is set. The records
+// carry the cache's TTL, which Go's resolver ignores.
+func hostResponse(query []byte, ips []net.IP, notFound bool) []byte {
+	// The answers go after the question, without the query's EDNS record.
+	resp := append([]byte(nil), query[:skipDNSName(query, 12)+4]...)
+	resp[2] = 0x80 | query[2]&0x01 // Response, with the query's recursion desired bit
+	resp[3] = 0x80                 // Recursion available
+	if notFound {
+		resp[3] |= 3 // NXDOMAIN
+	}
+	binary.BigEndian.PutUint16(resp[6:], uint16(len(ips)))
+	binary.BigEndian.PutUint16(resp[8:], 0)
+	binary.BigEndian.PutUint16(resp[10:], 0)
+	for _, ip := range ips {
+		rtype := uint16(28)
+		if len(ip) == net.IPv4len {
+			rtype = 1
+		}
+		resp = append(resp, 0xc0, 12) // The name points at the question
+		resp = binary.BigEndian.AppendUint16(resp, rtype)
+		resp = binary.BigEndian.AppendUint16(resp, 1)
+		resp = binary.BigEndian.AppendUint32(resp, uint32(dnsCacheTTL/time.Second))
+		resp = binary.BigEndian.AppendUint16(resp, uint16(len(ip)))
+		resp = append(resp, ip...)
+	}
+	return resp
+}
+
+// resolver answers the DNS queries of Go's built-in resolver from its
+// cache or by asking a DNS-over-HTTPS (RFC 8484) server. Answers are cached
+// for their TTL. It is shared by every request that uses the same
+// transport, so the cache is safe for concurrent use.
+type resolver struct {
+	dohURL string
+	client *http.Client
+
+	mu    sync.Mutex
+	cache map[string]dnsCacheEntry // nil turns caching off
+}
+
+// dnsCacheEntry is a cached DNS response and when it expires.
+type dnsCacheEntry struct {
+	response []byte
+	expires  time.Time
+}
+
+// newResolver returns a net.Resolver that sends its queries to the DoH
+// server at dohURL and caches the answers unless noCache is set.
+func newResolver(dohURL string, noCache bool) (*net.Resolver, error) {
+	u, err := url.Parse(dohURL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return nil, fmt.Errorf("invalid DNS-over-HTTPS URL %q (expected https://host/path)", dohURL)
+	}
+	// The DoH server itself is found with the system resolver.
+	r := &resolver{dohURL: dohURL, client: &http.Client{Timeout: 10 * time.Second}}
+	if !noCache {
+		r.cache = make(map[string]dnsCacheEntry)
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			return &dnsConn{ctx: ctx, exchange: r.exchange}, nil
+		},
+	}, nil
+}
+
+// exchange answers query from the cache or the DoH server.
+func (r *resolver) exchange(ctx context.Context, query []byte) ([]byte, error) {
+	key, name, qtype, err := dnsQuestionKey(query)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.cache != nil {
+		r.mu.Lock()
+		entry, ok := r.cache[key]
+		r.mu.Unlock()
+		if ok && time.Now().Before(entry.expires) {
+			traceDial(ctx, "DNS cache hit for %s (%s), expires in %s", name, qtype, time.Until(entry.expires).Round(time.Second))
+			return withDNSID(entry.response, query), nil
+		}
+	}
+
+	// The upstream query must not carry the request's context values, or
+	// its connection would be reported by the request's own trace callbacks.
+	upCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if deadline, ok := ctx.Deadline(); ok {
+		upCtx, cancel = context.WithDeadline(upCtx, deadline)
+		defer cancel()
+	}
+	defer context.AfterFunc(ctx, cancel)()
+
+	response, err := r.queryDoH(upCtx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	ttl, answers := dnsResponseTTL(response)
+	if r.cache == nil {
+		traceDial(ctx, "DNS answer for %s (%s) from %s: %d record(s)", name, qtype, r.dohURL, answers)
+	} else {
+		traceDial(ctx, "DNS cache miss for %s (%s), asked %s: %d record(s), TTL %s", name, qtype, r.dohURL, answers, ttl)
+	}
+	if r.cache != nil && ttl > 0 {
+		r.mu.Lock()
+		r.cache[key] = dnsCacheEntry{response: response, expires: time.Now().Add(ttl)}
+		r.mu.Unlock()
+	}
+	return withDNSID(response, query), nil
+}
+
+// queryDoH sends query to the DoH server.
+func (r *resolver) queryDoH(ctx context.Context, query []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.dohURL, bytes.NewReader(query))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/dns-message")
+	req.Header.Set("Accept", "application/dns-message")
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("DNS-over-HTTPS query failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("DNS-over-HTTPS server returned %s", resp.Status)
+	}
+	response, err := io.ReadAll(io.LimitReader(resp.Body, maxDNSMessage+1))
+	if err != nil {
+		return nil, fmt.Errorf("DNS-over-HTTPS query failed: %w", err)
+	}
+	if len(response) > maxDNSMessage || len(response) < 12 {
+		return nil, errors.New("DNS-over-HTTPS server returned an invalid message")
+	}
+	return response, nil
+}
+
+// dnsConn is the connection Go's resolver "dials" for every lookup. It
+// always uses the stream (TCP) framing of a two-byte length before each
+// message, since it does not implement net.PacketConn, and hands each
+// query to exchange.
+type dnsConn struct {
+	ctx      context.Context
+	exchange func(ctx context.Context, query []byte) ([]byte, error)
+	pending  []byte
+	deadline time.Time
+}
+
+// Write takes one length-prefixed query and prepares its response.
+func (c *dnsConn) Write(b []byte) (int, error) {
+	if len(b) < 2 || int(binary.BigEndian.Uint16(b)) != len(b)-2 {
+		return 0, errors.New("dns: incomplete query")
+	}
+	ctx := c.ctx
+	if !c.deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, c.deadline)
+		defer cancel()
+	}
+	response, err := c.exchange(ctx, b[2:])
+	if err != nil {
+		return 0, err
+	}
+	c.pending = binary.BigEndian.AppendUint16(nil, uint16(len(response)))
+	c.pending = append(c.pending, response...)
+	return len(b), nil
+}
+
+// Read returns the length-prefixed response to the last query.
+func (c *dnsConn) Read(b []byte) (int, error) {
+	if len(c.pending) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(b, c.pending)
+	c.pending = c.pending[n:]
+	return n, nil
+}
+
+func (c *dnsConn) Close() error                       { return nil }
+func (c *dnsConn) LocalAddr() net.Addr                { return dnsAddr{} }
+func (c *dnsConn) RemoteAddr() net.Addr               { return dnsAddr{} }
+func (c *dnsConn) SetDeadline(t time.Time) error      { c.deadline = t; return nil }
+func (c *dnsConn) SetReadDeadline(t time.Time) error  { return nil }
+func (c *dnsConn) SetWriteDeadline(t time.Time) error { c.deadline = t; return nil }
+
+// dnsAddr is the placeholder address of a dnsConn.
+type dnsAddr struct{}
+
+func (dnsAddr) Network() string { return "dns" }
+func (dnsAddr) String() string  { return "dns" }
+
+// dnsQuestionKey returns the cache key for the question of a DNS query,
+// along with the queried name and type for verbose output.
+func dnsQuestionKey(msg []byte) (key, name, qtype string, err error) {
+	if len(msg) < 12 || binary.BigEndian.Uint16(msg[4:]) != 1 {
+		return "", "", "", errors.New("dns: expected a query with one question")
+	}
+	var labels []string
+	off := 12
+	for {
+		if off >= len(msg) {
+			return "", "", "", errors.New("dns: truncated question")
+		}
+		n := int(msg[off])
+		off++
+		if n == 0 {
+			break
+		}
+		if n > 63 || off+n > len(msg) {
+			return "", "", "", errors.New("dns: invalid question name")
+		}
+		labels = append(labels, string(msg[off:off+n]))
+		off += n
+	}
+	if off+4 > len(msg) {
+		return "", "", "", errors.New("dns: truncated question")
+	}
+	name = strings.ToLower(strings.Join(labels, "."))
+	t := binary.BigEndian.Uint16(msg[off:])
+	qtype = dnsQueryTypes[t]
+	if qtype == "" {
+		qtype = fmt.Sprintf("type %d", t)
+	}
+	return fmt.Sprintf("%s/%d/%d", name, t, binary.BigEndian.Uint16(msg[off+2:])), name, qtype, nil
+}
+
+// dnsResponseTTL returns how long a response may be cached, the lowest TTL
+// of its answer and authority records (so negative answers are cached for
+// their SOA TTL), and the number of answers. A response that cannot be
+// parsed, or has no records, gets a TTL of 0.
+func dnsResponseTTL(msg []byte) (time.Duration, int) {
+	qd := int(binary.BigEndian.Uint16(msg[4:]))
+	an := int(binary.BigEndian.Uint16(msg[6:]))
+	ns := int(binary.BigEndian.Uint16(msg[8:]))
+	off := 12
+	for range qd {
+		off = skipDNSName(msg, off)
+		if off < 0 {
+			return 0, an
+		}
+		off += 4
+	}
+	minTTL := -1
+	for range an + ns {
+		off = skipDNSName(msg, off)
+		if off < 0 || off+10 > len(msg) {
+			return 0, an
+		}
+		ttl := int(binary.BigEndian.Uint32(msg[off+4:]))
+		if minTTL < 0 || ttl < minTTL {
+			minTTL = ttl
+		}
+		off += 10 + int(binary.BigEndian.Uint16(msg[off+8:]))
+	}
+	if minTTL < 0 || off > len(msg) {
+		return 0, an
+	}
+	return time.Duration(minTTL) * time.Second, an
+}
+
+// skipDNSName returns the offset after the (possibly compressed) name at
+// off, or -1 if the message is malformed.
+func skipDNSName(msg []byte, off int) int {
+	for off >= 0 && off < len(msg) {
+		n := int(msg[off])
+		switch {
+		case n == 0:
+			return off + 1
+		case n&0xc0 == 0xc0:
+			return off + 2 // A pointer ends the name
+		default:
+			off += 1 + n
+		}
+	}
+	return -1
+}
+
+// withDNSID returns a copy of response carrying the ID of query.
+func withDNSID(response, query []byte) []byte {
+	out := bytes.Clone(response)
+	copy(out[:2], query[:2])
+	return out
+}
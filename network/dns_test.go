@@ -0,0 +1,220 @@
+package network
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// dnsMessage builds a response to one question for example.com with the
+// given answer TTLs, each an A record whose name points at the question.
+func dnsMessage(ttls ...uint32) []byte {
+	msg := []byte{0, 1, 0x81, 0x80, 0, 1, 0, byte(len(ttls)), 0, 0, 0, 0}
+	msg = append(msg, 7, 'e', 'x', 'a', 'm', 'p', 'l', 'e', 3, 'c', 'o', 'm', 0, 0, 1, 0, 1)
+	for _, ttl := range ttls {
+		msg = append(msg, 0xc0, 12, 0, 1, 0, 1)
+		msg = binary.BigEndian.AppendUint32(msg, ttl)
+		msg = append(msg, 0, 4, 192, 0, 2, 1)
+	}
+	return msg
+}
+
+func TestDNSResponseTTL(t *testing.T) {
+	tests := []struct {
+		name    string
+		msg     []byte
+		ttl     time.Duration
+		answers int
+	}{
+		{"one answer", dnsMessage(300), 300 * time.Second, 1},
+		{"lowest TTL", dnsMessage(300, 60, 120), 60 * time.Second, 3},
+		{"no records", dnsMessage(), 0, 0},
+		{"truncated answer", dnsMessage(300)[:35], 0, 1},
+		{"truncated question", dnsMessage(300)[:16], 0, 1},
+		{"unterminated question", append(dnsMessage(300)[:12], 63, 'x'), 0, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ttl, answers := dnsResponseTTL(tt.msg)
+			if ttl != tt.ttl || answers != tt.answers {
+				t.Errorf("dnsResponseTTL = %s, %d; want %s, %d", ttl, answers, tt.ttl, tt.answers)
+			}
+		})
+	}
+}
+
+// cachedHost returns a finished hostCache entry for addrs.
+func cachedHost(expires time.Time, addrs ...net.IPAddr) *hostCacheEntry {
+	done := make(chan struct{})
+	close(done)
+	return &hostCacheEntry{done: done, addrs: addrs, expires: expires}
+}
+
+func TestHostCacheServesFreshEntries(t *testing.T) {
+	c := newHostCache()
+	localhost := net.IPAddr{IP: net.IPv4(127, 0, 0, 1)}
+	c.entries["cached.invalid"] = cachedHost(time.Now().Add(time.Minute), localhost)
+	c.entries["expired.invalid"] = cachedHost(time.Now().Add(-time.Second), localhost)
+
+	addrs, err := c.lookup(context.Background(), "Cached.Invalid")
+	if err != nil || len(addrs) != 1 || !addrs[0].IP.Equal(localhost.IP) {
+		t.Errorf("lookup of a cached host = %v, %v; want %v", addrs, err, localhost)
+	}
+	if _, err := c.lookup(context.Background(), "expired.invalid"); err == nil {
+		t.Error("lookup of an expired host succeeded; want the resolver's error for .invalid")
+	}
+	if _, ok := c.entries["expired.invalid"]; ok {
+		t.Error("failed lookup was cached")
+	}
+}
+
+func TestHostCacheResolver(t *testing.T) {
+	c := newHostCache()
+	c.entries["dual.invalid"] = cachedHost(time.Now().Add(time.Minute),
+		net.IPAddr{IP: net.ParseIP("2001:db8::1")}, net.IPAddr{IP: net.IPv4(192, 0, 2, 1)}, net.IPAddr{IP: net.IPv4(192, 0, 2, 2)})
+	r := c.resolver()
+
+	tests := []struct {
+		network string
+		want    string
+	}{
+		{"ip", "[192.0.2.1 192.0.2.2 2001:db8::1]"},
+		{"ip4", "[192.0.2.1 192.0.2.2]"},
+		{"ip6", "[2001:db8::1]"},
+	}
+	for _, tt := range tests {
+		ips, err := r.LookupIP(context.Background(), tt.network, "dual.invalid")
+		if err != nil {
+			t.Errorf("LookupIP(%s): %v", tt.network, err)
+			continue
+		}
+		slices.SortFunc(ips, func(a, b net.IP) int { return strings.Compare(a.String(), b.String()) })
+		if got := fmt.Sprint(ips); got != tt.want {
+			t.Errorf("LookupIP(%s) = %s, want %s", tt.network, got, tt.want)
+		}
+	}
+
+	_, err := r.LookupIPAddr(context.Background(), "missing.invalid")
+	var dnsErr *net.DNSError
+	if !errors.As(err, &dnsErr) || !dnsErr.IsNotFound {
+		t.Errorf("lookup of an unknown host error = %v, want a not found DNSError", err)
+	}
+}
+
+func TestHostCacheDialer(t *testing.T) {
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	_, port, _ := net.SplitHostPort(ln.Addr().String())
+
+	c := newHostCache()
+	// Nothing listens on the IPv6 address, so IPv4 must win either way.
+	c.entries["server.invalid"] = cachedHost(time.Now().Add(time.Minute),
+		net.IPAddr{IP: net.IPv6loopback}, net.IPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	for _, delay := range []time.Duration{0, 50 * time.Millisecond, -1} {
+		d := &net.Dialer{Timeout: 5 * time.Second, FallbackDelay: delay, Resolver: c.resolver()}
+		conn, err := d.DialContext(context.Background(), "tcp", net.JoinHostPort("server.invalid", port))
+		if err != nil {
+			t.Fatalf("DialContext with FallbackDelay %s: %v", delay, err)
+		}
+		if got := conn.RemoteAddr().String(); got != ln.Addr().String() {
+			t.Errorf("FallbackDelay %s connected to %s, want %s", delay, got, ln.Addr())
+		}
+		conn.Close()
+	}
+
+	d := &net.Dialer{Timeout: 5 * time.Second, Resolver: c.resolver()}
+	if conn, err := d.DialContext(context.Background(), "tcp6", net.JoinHostPort("server.invalid", port)); err == nil {
+		conn.Close()
+		t.Error("tcp6 dial to a host without an IPv6 listener succeeded")
+	}
+}
+
+// dohServer answers DNS-over-HTTPS queries for doh.test with 127.0.0.1 and
+// an empty answer for its AAAA records, counting the queries it gets.
+func dohServer(t *testing.T, queries *atomic.Int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/dns-message" {
+			t.Errorf("DoH request is %s with Content-Type %q", r.Method, r.Header.Get("Content-Type"))
+		}
+		query, _ := io.ReadAll(r.Body)
+		queries.Add(1)
+		_, name, qtype, err := dnsQuestionKey(query)
+		if err != nil {
+			t.Errorf("dnsQuestionKey: %v", err)
+			return
+		}
+		// The answer goes after the question, without the query's EDNS record.
+		resp := append([]byte(nil), query[:skipDNSName(query, 12)+4]...)
+		resp[2] = 0x81 // Response, recursion desired
+		resp[3] = 0x80 // Recursion available
+		resp[11] = 0
+		switch {
+		case name != "doh.test":
+			resp[3] |= 3 // NXDOMAIN
+		case qtype == "A":
+			resp[7] = 1
+			resp = append(resp, 0xc0, 12, 0, 1, 0, 1, 0, 0, 0x0e, 0x10, 0, 4, 127, 0, 0, 1)
+		}
+		w.Header().Set("Content-Type", "application/dns-message")
+		w.Write(resp)
+	}))
+}
+
+func TestDoHResolver(t *testing.T) {
+	var queries atomic.Int32
+	srv := dohServer(t, &queries)
+	defer srv.Close()
+
+	r, err := newResolver(srv.URL+"/dns-query", false)
+	if err != nil {
+		t.Fatalf("newResolver: %v", err)
+	}
+	for i := range 2 {
+		addrs, err := r.LookupIPAddr(context.Background(), "doh.test")
+		if err != nil {
+			t.Fatalf("lookup %d: %v", i+1, err)
+		}
+		if len(addrs) != 1 || !addrs[0].IP.Equal(net.IPv4(127, 0, 0, 1)) {
+			t.Errorf("lookup %d = %v, want [127.0.0.1]", i+1, addrs)
+		}
+	}
+	// The second lookup of the A record is answered from the cache; the
+	// empty AAAA answer has no TTL, so it is asked for again.
+	if got := queries.Load(); got != 3 {
+		t.Errorf("the DoH server got %d queries, want 3", got)
+	}
+
+	if _, err := r.LookupIPAddr(context.Background(), "missing.test"); err == nil {
+		t.Error("lookup of a name the server does not know succeeded")
+	}
+}
+
+func TestNewResolverRejectsInvalidURL(t *testing.T) {
+	for _, dohURL := range []string{"1.1.1.1", "ftp://dns.example/dns-query", "https:///dns-query"} {
+		if _, err := newResolver(dohURL, false); err == nil {
+			t.Errorf("newResolver(%q) succeeded", dohURL)
+		}
+	}
+}
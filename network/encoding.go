@@ -0,0 +1,107 @@
+package network
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// decodedBody closes both the decoding reader and the underlying response body.
+type decodedBody struct {
+	io.Reader
+	decoder io.Closer
+	body    io.Closer
+}
+
+// Close releases the decoder and then the original response body.
+func (d *decodedBody) Close() error {
+	derr := d.decoder.Close()
+	if err := d.body.Close(); err != nil {
+		return err
+	}
+	return derr
+}
+
+// AcceptEncoding is the Accept-Encoding header sent with --compressed,
+// listing every encoding DecodeBody understands.
+const AcceptEncoding = "gzip, deflate, br"
+
+// DecodeBody returns a reader over the response body that undoes the
+// Content-Encoding announced by the server (gzip, deflate or br).
+// Unknown or absent encodings return the body untouched, so binary
+// content is still passed through byte-for-byte.
+// Closing the returned reader also closes resp.Body.
+func DecodeBody(resp *http.Response) (io.ReadCloser, error) {
+	encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+
+	switch encoding {
+	case "gzip", "x-gzip":
+		gz, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding gzip body: %w", err)
+		}
+		return &decodedBody{Reader: gz, decoder: gz, body: resp.Body}, nil
+	case "deflate":
+		// "deflate" should be zlib-wrapped, but some servers send raw DEFLATE.
+		// Peek at the header to tell the two apart.
+		br := bufio.NewReader(resp.Body)
+		header, _ := br.Peek(2)
+		if len(header) == 2 && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+			zr, err := zlib.NewReader(br)
+			if err != nil {
+				return nil, fmt.Errorf("error decoding deflate body: %w", err)
+			}
+			return &decodedBody{Reader: zr, decoder: zr, body: resp.Body}, nil
+		}
+		fr := flate.NewReader(br)
+		return &decodedBody{Reader: fr, decoder: fr, body: resp.Body}, nil
+	case "br":
+		return &decodedBody{Reader: brotli.NewReader(resp.Body), decoder: io.NopCloser(nil), body: resp.Body}, nil
+	default:
+		return resp.Body, nil
+	}
+}
+
+// decompressResponse replaces resp.Body with its decoded form. Like the
+// transparent gzip support in net/http, it then removes the Content-Encoding
+// and Content-Length headers, which no longer describe the body. Responses
+// without a body (to HEAD, 204, 304 or with Content-Length: 0) are left
+// alone, since the decoders would fail to read a header from them.
+func decompressResponse(resp *http.Response) error {
+	if resp.Header.Get("Content-Encoding") == "" || !hasBody(resp) {
+		return nil
+	}
+	body, err := DecodeBody(resp)
+	if err != nil {
+		return err
+	}
+	if body == resp.Body {
+		// An encoding we cannot decode; leave it for the caller to see.
+		return nil
+	}
+	resp.Body = body
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return nil
+}
+
+// hasBody reports whether resp can carry a body.
+func hasBody(resp *http.Response) bool {
+	if resp.Request != nil && resp.Request.Method == http.MethodHead {
+		return false
+	}
+	switch resp.StatusCode {
+	case http.StatusNoContent, http.StatusNotModified:
+		return false
+	}
+	return resp.ContentLength != 0
+}
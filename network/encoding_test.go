@@ -0,0 +1,76 @@
+package network
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFetchCompressedWithoutBody(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		if r.URL.Path == "/not-modified" {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Length", "0")
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		status int
+	}{
+		{"HEAD", http.MethodHead, "/", http.StatusOK},
+		{"304", http.MethodGet, "/not-modified", http.StatusNotModified},
+		{"empty 200", http.MethodGet, "/", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := Fetch(RequestOptions{URL: srv.URL + tt.path, Method: tt.method, Compressed: true})
+			if err != nil {
+				t.Fatalf("Fetch: %v", err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tt.status {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+			if body, err := io.ReadAll(resp.Body); err != nil || len(body) != 0 {
+				t.Errorf("body = %q, %v; want empty", body, err)
+			}
+		})
+	}
+}
+
+func TestFetchCompressedDecodesGzip(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write([]byte("hello, gzip"))
+	gz.Close()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write(buf.Bytes())
+	}))
+	defer srv.Close()
+
+	resp, err := Fetch(RequestOptions{URL: srv.URL, Compressed: true})
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("reading body: %v", err)
+	}
+	if string(body) != "hello, gzip" {
+		t.Errorf("body = %q, want %q", body, "hello, gzip")
+	}
+	if resp.Header.Get("Content-Encoding") != "" {
+		t.Errorf("Content-Encoding = %q, want it removed", resp.Header.Get("Content-Encoding"))
+	}
+}
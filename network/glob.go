@@ -0,0 +1,150 @@
+package network
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ExpandURLGlob expands the curl-style globs in pattern into the URLs they
+// stand for: "{a,b,c}" lists alternatives, and "[1-10]", "[001-100]" (zero
+// padded), "[a-z]" and "[1-10:2]" (with a step) are ranges. Several globs
+// multiply, the leftmost varying slowest, but cannot be nested. A backslash
+// makes the next bracket or brace literal, and a bracketed IPv6 host such as
+// "[::1]" is left as it is. A pattern without globs is returned unchanged.
+func ExpandURLGlob(pattern string) ([]string, error) {
+	var segments [][]string // Each is a literal (one value) or a glob
+	var literal strings.Builder
+	flush := func() {
+		if literal.Len() > 0 {
+			segments = append(segments, []string{literal.String()})
+			literal.Reset()
+		}
+	}
+
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch c {
+		case '\\':
+			if i+1 < len(pattern) && strings.IndexByte("[]{}", pattern[i+1]) >= 0 {
+				i++
+				literal.WriteByte(pattern[i])
+			} else {
+				literal.WriteByte(c)
+			}
+		case '{':
+			end := strings.IndexByte(pattern[i:], '}')
+			if end < 0 {
+				return nil, fmt.Errorf("unmatched '{' at position %d in %q", i, pattern)
+			}
+			body := pattern[i+1 : i+end]
+			if j := strings.IndexAny(body, "{["); j >= 0 {
+				return nil, fmt.Errorf("nested '%c' at position %d in %q is not supported (use -g to turn off globbing)", body[j], i+1+j, pattern)
+			}
+			flush()
+			segments = append(segments, strings.Split(body, ","))
+			i += end
+		case '[':
+			end := strings.IndexByte(pattern[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unmatched '[' at position %d in %q", i, pattern)
+			}
+			body := pattern[i+1 : i+end]
+			values, err := expandGlobRange(body)
+			if err != nil {
+				if isIPv6Literal(body) {
+					literal.WriteString(pattern[i : i+end+1])
+					i += end
+					continue
+				}
+				return nil, fmt.Errorf("bad range [%s] in %q: %v (use -g to turn off globbing)", body, pattern, err)
+			}
+			flush()
+			segments = append(segments, values)
+			i += end
+		case ']', '}':
+			return nil, fmt.Errorf("unmatched '%c' at position %d in %q (use -g to turn off globbing)", c, i, pattern)
+		default:
+			literal.WriteByte(c)
+		}
+	}
+	flush()
+
+	urls := []string{""}
+	for _, seg := range segments {
+		next := make([]string, 0, len(urls)*len(seg))
+		for _, prefix := range urls {
+			for _, v := range seg {
+				next = append(next, prefix+v)
+			}
+		}
+		urls = next
+	}
+	return urls, nil
+}
+
+// expandGlobRange expands the inside of a "[...]" range: "start-end" with an
+// optional ":step", where start and end are both numbers or both letters of
+// the same case. A number with leading zeros sets the width of every value.
+func expandGlobRange(body string) ([]string, error) {
+	spec, stepText, hasStep := strings.Cut(body, ":")
+	step := 1
+	if hasStep {
+		var err error
+		step, err = strconv.Atoi(stepText)
+		if err != nil || step < 1 {
+			return nil, fmt.Errorf("invalid step %q", stepText)
+		}
+	}
+	startText, endText, ok := strings.Cut(spec, "-")
+	if !ok {
+		return nil, fmt.Errorf("expected start-end")
+	}
+
+	if isLetter(startText) && isLetter(endText) {
+		start, end := startText[0], endText[0]
+		if (start >= 'a') != (end >= 'a') || start > end {
+			return nil, fmt.Errorf("invalid letter range")
+		}
+		var values []string
+		for c := int(start); c <= int(end); c += step {
+			values = append(values, string(rune(c)))
+		}
+		return values, nil
+	}
+
+	start, err1 := strconv.Atoi(startText)
+	end, err2 := strconv.Atoi(endText)
+	if err1 != nil || err2 != nil || start < 0 || start > end {
+		return nil, fmt.Errorf("invalid numeric range")
+	}
+	width := 0
+	if len(startText) > 1 && startText[0] == '0' {
+		width = len(startText)
+	}
+	var values []string
+	for n := start; n <= end; n += step {
+		values = append(values, fmt.Sprintf("%0*d", width, n))
+	}
+	return values, nil
+}
+
+// isLetter reports whether s is a single ASCII letter.
+func isLetter(s string) bool {
+	return len(s) == 1 && (s[0] >= 'a' && s[0] <= 'z' || s[0] >= 'A' && s[0] <= 'Z')
+}
+
+// isIPv6Literal reports whether s looks like the inside of a bracketed IPv6
+// host, e.g. "::1" or "fe80::1%25eth0".
+func isIPv6Literal(s string) bool {
+	if !strings.Contains(s, ":") {
+		return false
+	}
+	host, _, _ := strings.Cut(s, "%")
+	for _, r := range host {
+		if !strings.ContainsRune("0123456789abcdefABCDEF:.", r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,80 @@
+package network
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExpandURLGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"http://h/plain", []string{"http://h/plain"}},
+		{"http://h/[1-5]", []string{"http://h/1", "http://h/2", "http://h/3", "http://h/4", "http://h/5"}},
+		{"http://h/[1-10:2]", []string{"http://h/1", "http://h/3", "http://h/5", "http://h/7", "http://h/9"}},
+		{"http://h/[3-3]", []string{"http://h/3"}},
+		{"http://h/[a-c]", []string{"http://h/a", "http://h/b", "http://h/c"}},
+		{"http://h/[A-E:2]", []string{"http://h/A", "http://h/C", "http://h/E"}},
+		{"http://h/{a,b}", []string{"http://h/a", "http://h/b"}},
+		{"http://h/{a,,b}", []string{"http://h/a", "http://h/", "http://h/b"}},
+		{"http://{x,y}.h/[1-2]", []string{"http://x.h/1", "http://x.h/2", "http://y.h/1", "http://y.h/2"}},
+		{"http://h/{a,b}[1-2].{png,jpg}", []string{
+			"http://h/a1.png", "http://h/a1.jpg", "http://h/a2.png", "http://h/a2.jpg",
+			"http://h/b1.png", "http://h/b1.jpg", "http://h/b2.png", "http://h/b2.jpg",
+		}},
+		{`http://h/\[1-2\]\{a\}`, []string{"http://h/[1-2]{a}"}},
+		{"http://[::1]:8080/[1-2]", []string{"http://[::1]:8080/1", "http://[::1]:8080/2"}},
+		{"http://[fe80::1%25eth0]/", []string{"http://[fe80::1%25eth0]/"}},
+	}
+	for _, tt := range tests {
+		got, err := ExpandURLGlob(tt.pattern)
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ExpandURLGlob(%q) = %q, %v; want %q", tt.pattern, got, err, tt.want)
+		}
+	}
+}
+
+func TestExpandURLGlobPadding(t *testing.T) {
+	got, err := ExpandURLGlob("http://h/[001-100]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 100 {
+		t.Fatalf("got %d URLs, want 100", len(got))
+	}
+	for i, u := range got {
+		if want := fmt.Sprintf("http://h/%03d", i+1); u != want {
+			t.Fatalf("URL %d = %q, want %q", i, u, want)
+		}
+	}
+}
+
+func TestExpandURLGlobErrors(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{"http://h/[5-1]", "bad range [5-1]"},
+		{"http://h/[c-a]", "bad range [c-a]"},
+		{"http://h/[a-Z]", "bad range [a-Z]"},
+		{"http://h/[1-10:0]", `invalid step "0"`},
+		{"http://h/[1-10:-1]", `invalid step "-1"`},
+		{"http://h/[1-x]", "invalid numeric range"},
+		{"http://h/[12]", "expected start-end"},
+		{"http://h/[1-5", "unmatched '[' at position 9"},
+		{"http://h/{a,b", "unmatched '{' at position 9"},
+		{"http://h/1-5]", "unmatched ']' at position 12"},
+		{"http://h/a}", "unmatched '}' at position 10"},
+		{"http://h/{a,{b,c}}", "nested '{' at position 12"},
+		{"http://h/{a,[1-2]}", "nested '[' at position 12"},
+	}
+	for _, tt := range tests {
+		got, err := ExpandURLGlob(tt.pattern)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ExpandURLGlob(%q) = %q, %v; want error containing %q", tt.pattern, got, err, tt.want)
+		}
+	}
+}
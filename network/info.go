@@ -0,0 +1,40 @@
+package network
+
+import (
+	"crypto/x509"
+	"io"
+	"net/http"
+	"sync/atomic"
+)
+
+// TransferInfo records details about how a request was carried out.
+type TransferInfo struct {
+	RemoteAddr     string              // Address (ip:port) of the server the final response came from
+	LocalAddr      string              // Local address (ip:port) of the connection the final response came on
+	Hops           []RedirectHop       // Each redirect that was followed, in order
+	RequestHeaders http.Header         // Headers of the final request as shown by -v (secrets redacted)
+	BytesSent      int64               // Request body bytes sent, including bodies replayed for redirects and retries
+	BytesReceived  int64               // Bytes of the final response body read so far, before decompression
+	PeerCerts      []*x509.Certificate // Certificate chain the server of the final response sent over TLS, leaf first
+}
+
+// RedirectHop describes one redirect that was followed.
+type RedirectHop struct {
+	From   string // URL of the request that was redirected
+	To     string // URL the Location header led to
+	Status int    // Status code of the redirect response, such as 301 or 302
+}
+
+// countingBody counts the bytes read through it into n. The transport may
+// read a request body on its own goroutine, so n is updated atomically.
+type countingBody struct {
+	io.ReadCloser
+	n *int64
+}
+
+// Read reads from the underlying body and adds what was read to the count.
+func (c countingBody) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	atomic.AddInt64(c.n, int64(n))
+	return n, err
+}
@@ -0,0 +1,139 @@
+package network
+
+import (
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// defaultFileContentType is used for file parts without a ;type= modifier.
+const defaultFileContentType = "application/octet-stream"
+
+// FormField is one part of a multipart/form-data body, as given to -F/--form.
+type FormField struct {
+	Name        string // Form field name
+	Value       string // Literal value, used when File is empty
+	File        string // Path of a file whose contents are uploaded
+	Filename    string // Filename reported for the part; defaults to the base name of File
+	ContentType string // Content-Type of the part; file parts default to application/octet-stream
+}
+
+// ParseFormField parses the name and value of a curl-style form argument
+// ("name=value" or "name=@file"). The value may end in ";type=mime/type"
+// and ";filename=name" modifiers.
+func ParseFormField(name, value string) (FormField, error) {
+	arg := name + "=" + value
+	if name == "" {
+		return FormField{}, fmt.Errorf("invalid form field %q (expected name=value or name=@file)", arg)
+	}
+
+	field := FormField{Name: name}
+	// Modifiers are only recognized at the end, so values may contain ';'.
+	for {
+		i := strings.LastIndex(value, ";")
+		if i < 0 {
+			break
+		}
+		key, modifier, _ := strings.Cut(value[i+1:], "=")
+		if key == "type" {
+			field.ContentType = modifier
+		} else if key == "filename" {
+			field.Filename = modifier
+		} else {
+			break
+		}
+		value = value[:i]
+	}
+
+	if file, ok := strings.CutPrefix(value, "@"); ok {
+		if file == "" {
+			return FormField{}, fmt.Errorf("invalid form field %q: missing file name after '@'", arg)
+		}
+		field.File = file
+		if field.Filename == "" {
+			field.Filename = filepath.Base(file)
+		}
+		if field.ContentType == "" {
+			field.ContentType = defaultFileContentType
+		}
+	} else {
+		field.Value = value
+	}
+	return field, nil
+}
+
+// NewMultipartBody returns a reader that streams a multipart/form-data body
+// for fields, along with the Content-Type (including the boundary) to send
+// with it. Files are opened up front so missing files are reported early,
+// but their contents are copied only as the body is read. Closing the reader
+// stops the stream.
+func NewMultipartBody(fields []FormField) (io.ReadCloser, string, error) {
+	files := make([]*os.File, len(fields))
+	for i, field := range fields {
+		if field.File == "" {
+			continue
+		}
+		f, err := os.Open(field.File)
+		if err != nil {
+			closeFiles(files)
+			return nil, "", fmt.Errorf("could not open form file: %w", err)
+		}
+		files[i] = f
+	}
+
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	go func() {
+		defer closeFiles(files)
+		for i, field := range fields {
+			if err := writeFormPart(mw, field, files[i]); err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+		}
+		pw.CloseWithError(mw.Close())
+	}()
+	return pr, mw.FormDataContentType(), nil
+}
+
+// writeFormPart writes one part, copying from file when it is non-nil.
+func writeFormPart(mw *multipart.Writer, field FormField, file *os.File) error {
+	disposition := fmt.Sprintf(`form-data; name="%s"`, quoteEscaper.Replace(field.Name))
+	if field.Filename != "" {
+		disposition += fmt.Sprintf(`; filename="%s"`, quoteEscaper.Replace(field.Filename))
+	}
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", disposition)
+	if field.ContentType != "" {
+		header.Set("Content-Type", field.ContentType)
+	}
+
+	part, err := mw.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	if file == nil {
+		_, err = io.WriteString(part, field.Value)
+		return err
+	}
+	if _, err := io.Copy(part, file); err != nil {
+		return fmt.Errorf("could not read form file %s: %w", field.File, err)
+	}
+	return nil
+}
+
+// quoteEscaper escapes quoted-string values in Content-Disposition headers.
+var quoteEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// closeFiles closes every non-nil file.
+func closeFiles(files []*os.File) {
+	for _, f := range files {
+		if f != nil {
+			f.Close()
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package network
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Netrc holds the credentials of a .netrc file.
+type Netrc struct {
+	Path     string       // File the entries were read from, for messages
+	Machines []NetrcEntry // Entries in file order; the default entry, if any, is last
+}
+
+// NetrcEntry is one "machine" (or "default") entry of a .netrc file.
+type NetrcEntry struct {
+	Machine  string // Host name; empty for the default entry
+	Login    string
+	Password string
+}
+
+// LoadNetrc reads the .netrc file at path. Like curl, it reports through
+// insecure whether the file can be read by users other than its owner, so
+// the caller can warn about it.
+func LoadNetrc(path string) (n *Netrc, insecure bool, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, false, err
+	}
+	defer f.Close()
+	if fi, err := f.Stat(); err == nil && fi.Mode().Perm()&0o077 != 0 {
+		insecure = true
+	}
+	machines, err := ParseNetrc(f)
+	if err != nil {
+		return nil, insecure, fmt.Errorf("%s: %w", path, err)
+	}
+	return &Netrc{Path: path, Machines: machines}, insecure, nil
+}
+
+// ParseNetrc parses the contents of a .netrc file: "machine", "login" and
+// "password" tokens, with "default" standing for every other host. Tokens
+// may be double-quoted to contain spaces. "account" values, macro
+// definitions ("macdef", up to the next blank line) and '#' comments are
+// skipped.
+func ParseNetrc(r io.Reader) ([]NetrcEntry, error) {
+	var entries []NetrcEntry
+	var current *NetrcEntry
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	inMacro := false
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Text()
+		if inMacro {
+			inMacro = strings.TrimSpace(line) != ""
+			continue
+		}
+		tokens, err := netrcTokens(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		for i := 0; i < len(tokens); i++ {
+			keyword := tokens[i]
+			switch keyword {
+			case "default":
+				entries = append(entries, NetrcEntry{})
+				current = &entries[len(entries)-1]
+				continue
+			case "macdef":
+				inMacro = true
+				i = len(tokens) // The rest of the line names the macro
+				continue
+			}
+			if i+1 >= len(tokens) {
+				return nil, fmt.Errorf("line %d: %q needs a value", lineNo, keyword)
+			}
+			i++
+			value := tokens[i]
+			switch keyword {
+			case "machine":
+				entries = append(entries, NetrcEntry{Machine: value})
+				current = &entries[len(entries)-1]
+			case "login", "password":
+				if current == nil {
+					return nil, fmt.Errorf("line %d: %q before any machine", lineNo, keyword)
+				}
+				if keyword == "login" {
+					current.Login = value
+				} else {
+					current.Password = value
+				}
+			case "account":
+			default:
+				return nil, fmt.Errorf("line %d: unknown token %q", lineNo, keyword)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// netrcTokens splits a .netrc line into tokens, stopping at a '#' comment.
+func netrcTokens(line string) ([]string, error) {
+	var tokens []string
+	for {
+		line = strings.TrimLeft(line, " \t\r")
+		if line == "" || line[0] == '#' {
+			return tokens, nil
+		}
+		if line[0] == '"' {
+			var token strings.Builder
+			i := 1
+			for ; i < len(line) && line[i] != '"'; i++ {
+				if line[i] == '\\' && i+1 < len(line) {
+					i++
+				}
+				token.WriteByte(line[i])
+			}
+			if i >= len(line) {
+				return nil, fmt.Errorf("unterminated quoted token")
+			}
+			tokens = append(tokens, token.String())
+			line = line[i+1:]
+			continue
+		}
+		end := strings.IndexAny(line, " \t\r")
+		if end < 0 {
+			end = len(line)
+		}
+		tokens = append(tokens, line[:end])
+		line = line[end:]
+	}
+}
+
+// Lookup returns the login and password for host: the first machine entry
+// with that name, or else the default entry. ok is false if neither exists.
+func (n *Netrc) Lookup(host string) (login, password string, ok bool) {
+	var def *NetrcEntry
+	for i, e := range n.Machines {
+		if e.Machine == "" {
+			if def == nil {
+				def = &n.Machines[i]
+			}
+			continue
+		}
+		if strings.EqualFold(e.Machine, host) {
+			return e.Login, e.Password, true
+		}
+	}
+	if def != nil {
+		return def.Login, def.Password, true
+	}
+	return "", "", false
+}
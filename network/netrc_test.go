@@ -0,0 +1,133 @@
+package network
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseNetrc(t *testing.T) {
+	data := `# Credentials
+machine example.com login alice password "s3cret pass"
+machine api.example.com
+	login bob
+	account ignored
+	password "quote\"d"
+macdef init
+cd /pub
+binary
+
+default login anonymous password guest@
+`
+	entries, err := ParseNetrc(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("ParseNetrc: %v", err)
+	}
+	want := []NetrcEntry{
+		{Machine: "example.com", Login: "alice", Password: "s3cret pass"},
+		{Machine: "api.example.com", Login: "bob", Password: `quote"d`},
+		{Login: "anonymous", Password: "guest@"},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("ParseNetrc = %+v, want %+v", entries, want)
+	}
+}
+
+func TestParseNetrcErrors(t *testing.T) {
+	tests := []struct {
+		data    string
+		wantErr string
+	}{
+		{"login alice", `line 1: "login" before any machine`},
+		{"machine example.com\nlogin", `line 2: "login" needs a value`},
+		{"machine example.com user alice", `line 1: unknown token "user"`},
+		{`machine example.com password "open`, "line 1: unterminated quoted token"},
+	}
+	for _, tt := range tests {
+		_, err := ParseNetrc(strings.NewReader(tt.data))
+		if err == nil || err.Error() != tt.wantErr {
+			t.Errorf("ParseNetrc(%q) error = %v, want %q", tt.data, err, tt.wantErr)
+		}
+	}
+}
+
+func TestNetrcLookup(t *testing.T) {
+	n := &Netrc{Machines: []NetrcEntry{
+		{Machine: "example.com", Login: "alice", Password: "a"},
+		{Login: "anonymous", Password: "guest"},
+		{Machine: "example.com", Login: "shadowed", Password: "b"},
+	}}
+	tests := []struct {
+		host, login, password string
+		ok                    bool
+	}{
+		{"example.com", "alice", "a", true},
+		{"EXAMPLE.COM", "alice", "a", true},
+		{"other.example", "anonymous", "guest", true},
+	}
+	for _, tt := range tests {
+		login, password, ok := n.Lookup(tt.host)
+		if login != tt.login || password != tt.password || ok != tt.ok {
+			t.Errorf("Lookup(%q) = %q, %q, %v; want %q, %q, %v", tt.host, login, password, ok, tt.login, tt.password, tt.ok)
+		}
+	}
+	if _, _, ok := (&Netrc{Machines: n.Machines[:1]}).Lookup("other.example"); ok {
+		t.Error("Lookup of an unknown host without a default entry succeeded")
+	}
+}
+
+func TestLoadNetrcReportsInsecureFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".netrc")
+	if err := os.WriteFile(path, []byte("machine example.com login alice password a\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, insecure, err := LoadNetrc(path); err != nil || insecure {
+		t.Errorf("LoadNetrc of a 0600 file = insecure %v, %v; want secure", insecure, err)
+	}
+	if err := os.Chmod(path, 0644); err != nil {
+		t.Fatal(err)
+	}
+	n, insecure, err := LoadNetrc(path)
+	if err != nil || !insecure {
+		t.Errorf("LoadNetrc of a 0644 file = insecure %v, %v; want insecure", insecure, err)
+	}
+	if n == nil || len(n.Machines) != 1 || n.Path != path {
+		t.Errorf("LoadNetrc = %+v", n)
+	}
+}
+
+func TestFetchUsesNetrc(t *testing.T) {
+	var user, pass string
+	var hasAuth bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, hasAuth = r.BasicAuth()
+	}))
+	defer srv.Close()
+	n := &Netrc{Machines: []NetrcEntry{{Machine: "127.0.0.1", Login: "alice", Password: "s3cret"}}}
+
+	tests := []struct {
+		name               string
+		opts               RequestOptions
+		wantUser, wantPass string
+	}{
+		{"from netrc", RequestOptions{URL: srv.URL, Netrc: n}, "alice", "s3cret"},
+		{"explicit user wins", RequestOptions{URL: srv.URL, Netrc: n, BasicAuthUser: "bob", BasicAuthPass: "pw"}, "bob", "pw"},
+		{"URL user wins", RequestOptions{URL: strings.Replace(srv.URL, "://", "://carol:pw2@", 1), Netrc: n}, "carol", "pw2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := Fetch(tt.opts)
+			if err != nil {
+				t.Fatalf("Fetch: %v", err)
+			}
+			resp.Body.Close()
+			if !hasAuth || user != tt.wantUser || pass != tt.wantPass {
+				t.Errorf("server got %q:%q (auth %v), want %q:%q", user, pass, hasAuth, tt.wantUser, tt.wantPass)
+			}
+		})
+	}
+}
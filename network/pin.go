@@ -0,0 +1,58 @@
+package network
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// pinPrefix starts every public key pin, as in curl's --pinnedpubkey.
+const pinPrefix = "sha256//"
+
+// ParsePinnedPubKey splits a --pinnedpubkey value of one or more
+// "sha256//BASE64" pins, separated by ';', and checks that each is the
+// base64 encoding of a SHA-256 hash.
+func ParsePinnedPubKey(value string) ([]string, error) {
+	var pins []string
+	for _, pin := range strings.Split(value, ";") {
+		pin = strings.TrimSpace(pin)
+		hash, ok := strings.CutPrefix(pin, pinPrefix)
+		if !ok {
+			return nil, fmt.Errorf("invalid pin %q (expected sha256//BASE64)", pin)
+		}
+		if raw, err := base64.StdEncoding.DecodeString(hash); err != nil || len(raw) != sha256.Size {
+			return nil, fmt.Errorf("invalid pin %q (not a base64 SHA-256 hash)", pin)
+		}
+		pins = append(pins, pin)
+	}
+	return pins, nil
+}
+
+// PublicKeyPin returns the pin of cert: the SHA-256 hash of its
+// SubjectPublicKeyInfo, in the "sha256//BASE64" form.
+func PublicKeyPin(cert *x509.Certificate) string {
+	hash := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return pinPrefix + base64.StdEncoding.EncodeToString(hash[:])
+}
+
+// verifyPinnedPubKey returns a tls.Config.VerifyConnection function that
+// fails the handshake unless the server's leaf certificate has one of the
+// pinned public keys. It runs after the usual certificate verification (or
+// instead of it with InsecureSkipTLS), and for resumed sessions too.
+func verifyPinnedPubKey(pins []string) func(tls.ConnectionState) error {
+	return func(cs tls.ConnectionState) error {
+		if len(cs.PeerCertificates) == 0 {
+			return errors.New("public key pinning: the server sent no certificate")
+		}
+		pin := PublicKeyPin(cs.PeerCertificates[0])
+		if !slices.Contains(pins, pin) {
+			return fmt.Errorf("public key pinning: the server's key %s matches none of the pinned keys", pin)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,77 @@
+package network
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePinnedPubKey(t *testing.T) {
+	hash := sha256.Sum256([]byte("key"))
+	pin := "sha256//" + base64.StdEncoding.EncodeToString(hash[:])
+	other := sha256.Sum256([]byte("other key"))
+	otherPin := "sha256//" + base64.StdEncoding.EncodeToString(other[:])
+
+	pins, err := ParsePinnedPubKey(pin + " ; " + otherPin)
+	if err != nil {
+		t.Fatalf("ParsePinnedPubKey: %v", err)
+	}
+	if want := []string{pin, otherPin}; !reflect.DeepEqual(pins, want) {
+		t.Errorf("ParsePinnedPubKey = %v, want %v", pins, want)
+	}
+
+	for _, value := range []string{
+		"",
+		"sha1//" + base64.StdEncoding.EncodeToString(hash[:20]),
+		"sha256//not base64!",
+		"sha256//" + base64.StdEncoding.EncodeToString(hash[:16]),
+		pin + ";",
+		"/path/to/key.pem",
+	} {
+		if _, err := ParsePinnedPubKey(value); err == nil {
+			t.Errorf("ParsePinnedPubKey(%q) succeeded", value)
+		}
+	}
+}
+
+func TestFetchPinnedPubKey(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0) // The failed handshake is expected
+	srv.StartTLS()
+	defer srv.Close()
+	pin := PublicKeyPin(srv.Certificate())
+	hash := sha256.Sum256([]byte("some other key"))
+	wrongPin := "sha256//" + base64.StdEncoding.EncodeToString(hash[:])
+
+	tests := []struct {
+		name    string
+		pins    []string
+		wantErr bool
+	}{
+		{"matching pin", []string{pin}, false},
+		{"one of several pins", []string{wrongPin, pin}, false},
+		{"no matching pin", []string{wrongPin}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The test server's certificate is self-signed, so only the pin
+			// can vouch for it.
+			resp, err := Fetch(RequestOptions{URL: srv.URL, InsecureSkipTLS: true, PinnedPubKeys: tt.pins})
+			if resp != nil {
+				resp.Body.Close()
+			}
+			switch {
+			case tt.wantErr && (err == nil || !strings.Contains(err.Error(), "matches none of the pinned keys")):
+				t.Errorf("Fetch error = %v, want a pinning failure", err)
+			case !tt.wantErr && err != nil:
+				t.Errorf("Fetch: %v", err)
+			}
+		})
+	}
+}
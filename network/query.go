@@ -0,0 +1,68 @@
+package network
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// appendQuery adds data to an existing raw query string, separated by "&".
+func appendQuery(rawQuery, data string) string {
+	data = escapeQuery(data)
+	if rawQuery == "" || data == "" {
+		return rawQuery + data
+	}
+	return rawQuery + "&" + data
+}
+
+// escapeQuery percent-encodes the bytes of s that may not appear in a URL
+// query, such as spaces and non-ASCII characters. Data that is already
+// encoded, including its "&" and "=" separators and %XX escapes, is left
+// untouched. Spaces become "+" as in form encoding.
+func escapeQuery(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == ' ':
+			b.WriteByte('+')
+		case c == '%' && i+2 < len(s) && isHex(s[i+1]) && isHex(s[i+2]):
+			b.WriteByte(c)
+		case c == '%' || !isQueryByte(c):
+			fmt.Fprintf(&b, "%%%02X", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// isQueryByte reports whether c may appear unescaped in a query (RFC 3986
+// unreserved characters, sub-delimiters, ":", "@", "/" and "?").
+func isQueryByte(c byte) bool {
+	switch {
+	case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		return true
+	}
+	return strings.IndexByte("-._~!$&'()*+,;=:@/?", c) >= 0
+}
+
+// isHex reports whether c is a hexadecimal digit.
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+// encodeQueryParams URL-encodes "name=value" parameters (--url-query) into
+// a query string, in the order given. Both sides are encoded, so values may
+// contain '&' and '='; a parameter without '=' is sent as a bare name.
+func encodeQueryParams(params []string) string {
+	parts := make([]string, len(params))
+	for i, p := range params {
+		name, value, ok := strings.Cut(p, "=")
+		parts[i] = url.QueryEscape(name)
+		if ok {
+			parts[i] += "=" + url.QueryEscape(value)
+		}
+	}
+	return strings.Join(parts, "&")
+}
@@ -0,0 +1,66 @@
+package network
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAppendQuery(t *testing.T) {
+	tests := []struct {
+		rawQuery, data string
+		want           string
+	}{
+		{"a=1", "b=2", "a=1&b=2"},
+		{"", "b=2", "b=2"},
+		{"a=1", "", "a=1"},
+		{"", "", ""},
+		{"", "q=hello world", "q=hello+world"},
+		{"", "name=J%C3%B6rg&x=a%2Fb", "name=J%C3%B6rg&x=a%2Fb"},
+		{"", "pct=100%", "pct=100%25"},
+		{"", "k=é", "k=%C3%A9"},
+	}
+	for _, tt := range tests {
+		if got := appendQuery(tt.rawQuery, tt.data); got != tt.want {
+			t.Errorf("appendQuery(%q, %q) = %q, want %q", tt.rawQuery, tt.data, got, tt.want)
+		}
+	}
+}
+
+func TestDoDataAsQuery(t *testing.T) {
+	type request struct {
+		method, rawQuery, body string
+	}
+	var got request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got = request{r.Method, r.URL.RawQuery, string(body)}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		url, data string
+		want      string
+	}{
+		{"/?a=1", "b=2", "a=1&b=2"},
+		{"/", "b=2", "b=2"},
+		{"/?a=1", "", "a=1"},
+	}
+	for _, tt := range tests {
+		result, err := Do(RequestOptions{
+			URL:         srv.URL + tt.url,
+			Method:      http.MethodGet,
+			Body:        strings.NewReader(tt.data),
+			DataAsQuery: true,
+		})
+		if err != nil {
+			t.Fatalf("Do(%q, -d %q): %v", tt.url, tt.data, err)
+		}
+		result.Response.Body.Close()
+		if want := (request{http.MethodGet, tt.want, ""}); got != want {
+			t.Errorf("Do(%q, -d %q) sent %+v, want %+v", tt.url, tt.data, got, want)
+		}
+	}
+}
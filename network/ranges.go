@@ -0,0 +1,46 @@
+package network
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ValidateRange checks a byte range specification as accepted by -r/--range:
+// one or more comma-separated ranges of the form "START-END", "START-"
+// (from START to the end) or "-N" (the last N bytes).
+func ValidateRange(spec string) error {
+	if spec == "" {
+		return fmt.Errorf("empty range")
+	}
+	for _, r := range strings.Split(spec, ",") {
+		start, end, ok := strings.Cut(strings.TrimSpace(r), "-")
+		if !ok || (start == "" && end == "") {
+			return fmt.Errorf("invalid range %q (expected START-END, START- or -N)", r)
+		}
+		first, err := parseRangeBound(start)
+		if err != nil {
+			return fmt.Errorf("invalid range %q: %w", r, err)
+		}
+		last, err := parseRangeBound(end)
+		if err != nil {
+			return fmt.Errorf("invalid range %q: %w", r, err)
+		}
+		if start != "" && end != "" && first > last {
+			return fmt.Errorf("invalid range %q: start is after end", r)
+		}
+	}
+	return nil
+}
+
+// parseRangeBound parses one side of a range; an empty bound is allowed.
+func parseRangeBound(bound string) (uint64, error) {
+	if bound == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseUint(bound, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a byte offset", bound)
+	}
+	return n, nil
+}
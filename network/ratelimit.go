@@ -0,0 +1,54 @@
+package network
+
+import (
+	"io"
+	"time"
+)
+
+// rateLimitedBody is a response body read no faster than a fixed rate. It is
+// a token bucket: tokens (bytes) accrue at rate per second up to burst, and
+// each read may take at most the tokens available.
+type rateLimitedBody struct {
+	io.ReadCloser
+	rate   float64 // Bytes per second
+	burst  float64 // Maximum bytes per read; a tenth of a second's worth
+	tokens float64
+	last   time.Time
+}
+
+// newRateLimitedBody wraps body so that it is read at no more than rate
+// bytes per second.
+func newRateLimitedBody(body io.ReadCloser, rate int64) *rateLimitedBody {
+	return &rateLimitedBody{
+		ReadCloser: body,
+		rate:       float64(rate),
+		burst:      max(float64(rate)/10, 1),
+		last:       time.Now(),
+	}
+}
+
+// Read waits until at least one byte may be read, then reads as many bytes
+// as the bucket allows.
+func (b *rateLimitedBody) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return b.ReadCloser.Read(p)
+	}
+	b.refill()
+	if b.tokens < 1 {
+		time.Sleep(time.Duration((1 - b.tokens) / b.rate * float64(time.Second)))
+		b.refill()
+	}
+	if n := int(b.tokens); n < len(p) {
+		p = p[:n]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.tokens -= float64(n)
+	return n, err
+}
+
+// refill adds the tokens earned since the previous refill.
+func (b *rateLimitedBody) refill() {
+	now := time.Now()
+	b.tokens = min(b.tokens+now.Sub(b.last).Seconds()*b.rate, b.burst)
+	b.last = now
+}
@@ -0,0 +1,217 @@
+package network
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// redirectChain serves /a -> /b -> /c -> /final with a 301, a 302 and a 307.
+func redirectChain() *httptest.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/a", http.RedirectHandler("/b", http.StatusMovedPermanently))
+	mux.Handle("/b", http.RedirectHandler("/c", http.StatusFound))
+	mux.Handle("/c", http.RedirectHandler("/final", http.StatusTemporaryRedirect))
+	mux.HandleFunc("/final", func(w http.ResponseWriter, r *http.Request) {})
+	return httptest.NewServer(mux)
+}
+
+func TestDoRecordsRedirectHops(t *testing.T) {
+	srv := redirectChain()
+	defer srv.Close()
+
+	var diag strings.Builder
+	result, err := Do(RequestOptions{
+		URL:             srv.URL + "/a",
+		FollowRedirects: true,
+		MaxRedirects:    -1,
+		Verbose:         VerboseLines,
+		Diagnostics:     &diag,
+	})
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	result.Response.Body.Close()
+
+	want := []RedirectHop{
+		{From: srv.URL + "/a", To: srv.URL + "/b", Status: http.StatusMovedPermanently},
+		{From: srv.URL + "/b", To: srv.URL + "/c", Status: http.StatusFound},
+		{From: srv.URL + "/c", To: srv.URL + "/final", Status: http.StatusTemporaryRedirect},
+	}
+	if !reflect.DeepEqual(result.Info.Hops, want) {
+		t.Errorf("Hops = %+v, want %+v", result.Info.Hops, want)
+	}
+	for _, line := range []string{"Following redirect 1 (301)", "Following redirect 3 (307)"} {
+		if !strings.Contains(diag.String(), line) {
+			t.Errorf("verbose output lacks %q:\n%s", line, diag.String())
+		}
+	}
+}
+
+func TestDoStopsAtMaxRedirects(t *testing.T) {
+	srv := redirectChain()
+	defer srv.Close()
+
+	result, err := Do(RequestOptions{URL: srv.URL + "/a", FollowRedirects: true, MaxRedirects: 2})
+	if result != nil {
+		result.Response.Body.Close()
+	}
+	if err == nil || !strings.Contains(err.Error(), "maximum (2) redirects followed") {
+		t.Fatalf("Do error = %v, want the redirect limit", err)
+	}
+}
+
+func TestDoWithoutFollowingRedirects(t *testing.T) {
+	srv := redirectChain()
+	defer srv.Close()
+
+	result, err := Do(RequestOptions{URL: srv.URL + "/a"})
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	result.Response.Body.Close()
+	if result.Response.StatusCode != http.StatusMovedPermanently || len(result.Info.Hops) != 0 {
+		t.Errorf("got %d with hops %v, want the 301 itself and no hops", result.Response.StatusCode, result.Info.Hops)
+	}
+}
+
+func TestSameHost(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"http://example.com/a", "http://example.com/b", true},
+		{"http://example.com/", "http://EXAMPLE.com:80/", true},
+		{"https://example.com/", "https://example.com:443/", true},
+		{"http://example.com/", "https://example.com/", false},
+		{"http://example.com/", "http://example.com:8080/", false},
+		{"http://example.com/", "http://api.example.com/", false},
+		{"http://[::1]:8080/", "http://[::1]:8080/x", true},
+	}
+	for _, tt := range tests {
+		a, _ := url.Parse(tt.a)
+		b, _ := url.Parse(tt.b)
+		if got := sameHost(a, b); got != tt.want {
+			t.Errorf("sameHost(%s, %s) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestRedirectCredentials(t *testing.T) {
+	var gotAuth, gotCookie string
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth, gotCookie = r.Header.Get("Authorization"), r.Header.Get("Cookie")
+	}))
+	defer other.Close()
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/same":
+			http.Redirect(w, r, "/final", http.StatusFound)
+		case "/cross":
+			http.Redirect(w, r, other.URL+"/final", http.StatusFound)
+		default:
+			gotAuth, gotCookie = r.Header.Get("Authorization"), r.Header.Get("Cookie")
+		}
+	}))
+	defer origin.Close()
+
+	tests := []struct {
+		name            string
+		path            string
+		locationTrusted bool
+		wantSent        bool
+	}{
+		{"same host", "/same", false, true},
+		{"cross host", "/cross", false, false},
+		{"cross host trusted", "/cross", true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotAuth, gotCookie = "", ""
+			result, err := Do(RequestOptions{
+				URL:             origin.URL + tt.path,
+				CustomHeaders:   []string{"Authorization: Bearer secret"},
+				Cookie:          "session=abc",
+				FollowRedirects: true,
+				MaxRedirects:    -1,
+				LocationTrusted: tt.locationTrusted,
+			})
+			if err != nil {
+				t.Fatalf("Do: %v", err)
+			}
+			result.Response.Body.Close()
+			wantAuth, wantCookie := "", ""
+			if tt.wantSent {
+				wantAuth, wantCookie = "Bearer secret", "session=abc"
+			}
+			if gotAuth != wantAuth || gotCookie != wantCookie {
+				t.Errorf("redirect target got Authorization %q and Cookie %q, want %q and %q", gotAuth, gotCookie, wantAuth, wantCookie)
+			}
+		})
+	}
+}
+
+func TestAutoReferer(t *testing.T) {
+	var mu sync.Mutex
+	referers := make(map[string]string)
+	mux := http.NewServeMux()
+	record := func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		referers[r.URL.Path] = r.Header.Get("Referer")
+		mu.Unlock()
+	}
+	mux.HandleFunc("/a", func(w http.ResponseWriter, r *http.Request) {
+		record(w, r)
+		http.Redirect(w, r, "/b?x=1#frag", http.StatusFound)
+	})
+	mux.HandleFunc("/b", func(w http.ResponseWriter, r *http.Request) {
+		record(w, r)
+		http.Redirect(w, r, "/final", http.StatusFound)
+	})
+	mux.HandleFunc("/final", record)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	tests := []struct {
+		name        string
+		autoReferer bool
+		want        map[string]string
+	}{
+		{"auto", true, map[string]string{
+			"/a":     "https://start.example/",
+			"/b":     srv.URL + "/a",
+			"/final": srv.URL + "/b?x=1",
+		}},
+		{"fixed", false, map[string]string{
+			"/a":     "https://start.example/",
+			"/b":     "https://start.example/",
+			"/final": "https://start.example/",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clear(referers)
+			u := strings.Replace(srv.URL, "://", "://user:pw@", 1) + "/a"
+			result, err := Do(RequestOptions{
+				URL:             u,
+				Referer:         "https://start.example/",
+				AutoReferer:     tt.autoReferer,
+				FollowRedirects: true,
+				MaxRedirects:    -1,
+			})
+			if err != nil {
+				t.Fatalf("Do: %v", err)
+			}
+			result.Response.Body.Close()
+			mu.Lock()
+			defer mu.Unlock()
+			if !reflect.DeepEqual(referers, tt.want) {
+				t.Errorf("Referer headers = %v, want %v", referers, tt.want)
+			}
+		})
+	}
+}
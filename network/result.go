@@ -0,0 +1,43 @@
+package network
+
+import (
+	"context"
+	"net/http"
+)
+
+// Result bundles the final response of a request with what is known about
+// how it was obtained.
+type Result struct {
+	Response     *http.Response // Final response; the caller must close its body
+	EffectiveURL string         // URL of the request that produced Response, after redirects
+	Timings      Timings        // Phase timings; call Timings.Finish once the body is read
+	Info         TransferInfo   // Connection and redirect details, including the remote and local addresses and each redirect hop
+}
+
+// Do performs the request described by opts and returns its Result. It is
+// the entry point for using hurl's request handling as a library; unlike
+// Fetch, the timings and transfer details are part of the result, so
+// opts.Timings and opts.Info are ignored.
+func Do(opts RequestOptions) (*Result, error) {
+	return DoContext(context.Background(), opts)
+}
+
+// DoContext is like Do, but the request is abandoned when ctx is done. As
+// with FetchContext, a non-nil Result may accompany an error, and its
+// Response body must then be closed too.
+func DoContext(ctx context.Context, opts RequestOptions) (*Result, error) {
+	result := &Result{}
+	opts.Timings = &result.Timings
+	opts.Info = &result.Info
+
+	resp, err := FetchContext(ctx, opts)
+	if resp == nil {
+		return nil, err
+	}
+	result.Response = resp
+	result.EffectiveURL = opts.URL
+	if resp.Request != nil {
+		result.EffectiveURL = resp.Request.URL.String()
+	}
+	return result, err
+}
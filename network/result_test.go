@@ -0,0 +1,90 @@
+package network
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDoRecordsAddresses(t *testing.T) {
+	var clientAddr string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clientAddr = r.RemoteAddr
+	}))
+	defer srv.Close()
+
+	result, err := Do(RequestOptions{URL: srv.URL})
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	result.Response.Body.Close()
+	if want := srv.Listener.Addr().String(); result.Info.RemoteAddr != want {
+		t.Errorf("RemoteAddr = %q, want the server's %q", result.Info.RemoteAddr, want)
+	}
+	if result.Info.LocalAddr != clientAddr {
+		t.Errorf("LocalAddr = %q, want the address the server saw, %q", result.Info.LocalAddr, clientAddr)
+	}
+	if host, _, err := net.SplitHostPort(result.Info.LocalAddr); err != nil || net.ParseIP(host) == nil {
+		t.Errorf("LocalAddr %q is not an ip:port address", result.Info.LocalAddr)
+	}
+}
+
+func TestDoEffectiveURL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+	defer srv.Close()
+
+	result, err := Do(RequestOptions{URL: srv.URL + "/path?q=1"})
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	result.Response.Body.Close()
+	if want := srv.URL + "/path?q=1"; result.EffectiveURL != want {
+		t.Errorf("EffectiveURL = %q, want %q", result.EffectiveURL, want)
+	}
+	if len(result.Info.Hops) != 0 {
+		t.Errorf("a request without redirects recorded hops %v", result.Info.Hops)
+	}
+}
+
+func TestDoCountsBodyBytes(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "/final", http.StatusTemporaryRedirect)
+			return
+		}
+		io.WriteString(w, "abcdef")
+	}))
+	defer srv.Close()
+
+	result, err := Do(RequestOptions{
+		Method:          http.MethodPost,
+		URL:             srv.URL + "/redirect",
+		Body:            strings.NewReader("hello"),
+		FollowRedirects: true,
+		MaxRedirects:    -1,
+	})
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	body, _ := io.ReadAll(result.Response.Body)
+	result.Response.Body.Close()
+	if string(body) != "abcdef" {
+		t.Fatalf("body = %q, want %q", body, "abcdef")
+	}
+	// The 307 makes the body be sent twice.
+	if result.Info.BytesSent != 10 {
+		t.Errorf("BytesSent = %d, want 10", result.Info.BytesSent)
+	}
+	if result.Info.BytesReceived != 6 {
+		t.Errorf("BytesReceived = %d, want 6", result.Info.BytesReceived)
+	}
+	if want := srv.URL + "/final"; result.EffectiveURL != want {
+		t.Errorf("EffectiveURL = %q, want %q", result.EffectiveURL, want)
+	}
+}
@@ -0,0 +1,94 @@
+package network
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// defaultRetryDelay is the base backoff delay when RetryDelay is not set.
+const defaultRetryDelay = time.Second
+
+// maxRetryDelay caps the exponential backoff between attempts.
+const maxRetryDelay = 2 * time.Minute
+
+// retryReason explains why an attempt should be retried, or returns an
+// empty string if the result is final. Connection errors and timeouts are
+// always retried; responses are retried when their status is in statuses,
+// or 429 and 5xx when no statuses are configured.
+func retryReason(resp *http.Response, err error, statuses []int) string {
+	if err != nil {
+		if isRetryableError(err) {
+			return err.Error()
+		}
+		return ""
+	}
+	retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	if len(statuses) > 0 {
+		retry = slices.Contains(statuses, resp.StatusCode)
+	}
+	if retry {
+		return fmt.Sprintf("HTTP %s", resp.Status)
+	}
+	return ""
+}
+
+// isRetryableError reports whether err looks transient: a timeout, a failed
+// dial or read, or a connection closed mid-response. Certificate problems and
+// redirect limits are not retried.
+func isRetryableError(err error) bool {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) && urlErr.Timeout() {
+		return true
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return true
+	}
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// retryDelay computes how long to wait before the given retry attempt
+// (starting at 1). A Retry-After header on a 429 or 503 response is honored;
+// otherwise the base delay doubles each attempt, plus up to 50% random jitter.
+func retryDelay(base time.Duration, attempt int, resp *http.Response) time.Duration {
+	if resp != nil && (resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable) {
+		if d, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			return d
+		}
+	}
+
+	if base <= 0 {
+		base = defaultRetryDelay
+	}
+	delay := base << (attempt - 1)
+	if delay <= 0 || delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay + rand.N(delay/2+1)
+}
+
+// parseRetryAfter parses a Retry-After value given in seconds or as an HTTP date.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(value); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		d := time.Until(t)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
@@ -0,0 +1,75 @@
+package network
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ErrMaxFileSize is returned when a response body is larger than
+// RequestOptions.MaxFileSize.
+var ErrMaxFileSize = errors.New("maximum file size exceeded")
+
+// ParseSize parses a byte count with an optional k, m or g suffix (powers of
+// 1024, in either case), such as "500k" or "10M".
+func ParseSize(s string) (int64, error) {
+	number := strings.TrimSpace(s)
+	multiplier := int64(1)
+	if n := len(number); n > 0 {
+		switch number[n-1] {
+		case 'k', 'K':
+			multiplier = 1 << 10
+		case 'm', 'M':
+			multiplier = 1 << 20
+		case 'g', 'G':
+			multiplier = 1 << 30
+		}
+		if multiplier > 1 {
+			number = number[:n-1]
+		}
+	}
+	n, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || n < 0 || n > (1<<63-1)/multiplier {
+		return 0, fmt.Errorf("invalid size %q (expected a byte count such as 500k or 10M)", s)
+	}
+	return n * multiplier, nil
+}
+
+// checkMaxFileSize rejects resp when its declared length is over max, and
+// otherwise limits its body so reading past max fails with ErrMaxFileSize.
+func checkMaxFileSize(resp *http.Response, max int64) error {
+	if resp.ContentLength > max {
+		return fmt.Errorf("%w: the response is %d bytes, more than the limit of %d", ErrMaxFileSize, resp.ContentLength, max)
+	}
+	resp.Body = &maxSizeBody{ReadCloser: resp.Body, remaining: max, max: max}
+	return nil
+}
+
+// maxSizeBody is a response body that fails once more than max bytes have
+// been read from it.
+type maxSizeBody struct {
+	io.ReadCloser
+	remaining int64
+	max       int64
+}
+
+// Read reads from the underlying body, failing with ErrMaxFileSize as soon
+// as the body turns out to be larger than the limit.
+func (b *maxSizeBody) Read(p []byte) (int, error) {
+	if b.remaining < 0 {
+		return 0, fmt.Errorf("%w: the response is larger than the limit of %d bytes", ErrMaxFileSize, b.max)
+	}
+	// Read one byte past the limit to tell "exactly max" from "too large".
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	if b.remaining < 0 {
+		return n + int(b.remaining), fmt.Errorf("%w: the response is larger than the limit of %d bytes", ErrMaxFileSize, b.max)
+	}
+	return n, err
+}
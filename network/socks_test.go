@@ -0,0 +1,173 @@
+package network
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// socksServer is a minimal SOCKS5 proxy (RFC 1928) for tests. If user is
+// set it requires user name and password authentication (RFC 1929).
+type socksServer struct {
+	ln             net.Listener
+	user, password string
+
+	mu      sync.Mutex
+	targets []string // CONNECT destinations, as host:port
+}
+
+func newSOCKSServer(t *testing.T, user, password string) *socksServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &socksServer{ln: ln, user: user, password: password}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *socksServer) serve(conn net.Conn) {
+	defer conn.Close()
+	var head [2]byte
+	if _, err := io.ReadFull(conn, head[:]); err != nil || head[0] != 5 {
+		return
+	}
+	methods := make([]byte, head[1])
+	if _, err := io.ReadFull(conn, methods); err != nil {
+		return
+	}
+	want := byte(0x00)
+	if s.user != "" {
+		want = 0x02
+	}
+	if !strings.ContainsRune(string(methods), rune(want)) {
+		conn.Write([]byte{5, 0xff})
+		return
+	}
+	conn.Write([]byte{5, want})
+	if s.user != "" {
+		var buf [256]byte
+		io.ReadFull(conn, buf[:2])
+		user := make([]byte, buf[1])
+		io.ReadFull(conn, user)
+		io.ReadFull(conn, buf[:1])
+		password := make([]byte, buf[0])
+		io.ReadFull(conn, password)
+		if string(user) != s.user || string(password) != s.password {
+			conn.Write([]byte{1, 1})
+			return
+		}
+		conn.Write([]byte{1, 0})
+	}
+
+	var req [4]byte
+	if _, err := io.ReadFull(conn, req[:]); err != nil || req[1] != 1 {
+		return
+	}
+	var host string
+	switch req[3] {
+	case 1, 4:
+		ip := make(net.IP, 4)
+		if req[3] == 4 {
+			ip = make(net.IP, 16)
+		}
+		io.ReadFull(conn, ip)
+		host = ip.String()
+	case 3:
+		var n [1]byte
+		io.ReadFull(conn, n[:])
+		name := make([]byte, n[0])
+		io.ReadFull(conn, name)
+		host = string(name)
+	}
+	var port [2]byte
+	io.ReadFull(conn, port[:])
+	target := net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port[:]))))
+	s.mu.Lock()
+	s.targets = append(s.targets, target)
+	s.mu.Unlock()
+
+	upstream, err := net.Dial("tcp", target)
+	if err != nil {
+		conn.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	defer upstream.Close()
+	conn.Write([]byte{5, 0, 0, 1, 127, 0, 0, 1, 0, 0})
+	go io.Copy(upstream, conn)
+	io.Copy(conn, upstream)
+}
+
+func TestFetchThroughSOCKS5(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "via proxy")
+	}))
+	defer srv.Close()
+	_, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+
+	tests := []struct {
+		name       string
+		user, pass string // Required by the proxy
+		proxyUser  string // Userinfo in the proxy URL
+		scheme     string
+		wantTarget string // "ip" for any IP address and the server's port
+		wantErr    string
+	}{
+		{"no auth, proxy resolves", "", "", "", "socks5h", "localhost:" + port, ""},
+		{"no auth, resolved locally", "", "", "", "socks5", "ip", ""},
+		{"user and password", "alice", "s3cret", "alice:s3cret@", "socks5h", "localhost:" + port, ""},
+		{"wrong password", "alice", "s3cret", "alice:wrong@", "socks5h", "", "authentication failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proxy := newSOCKSServer(t, tt.user, tt.pass)
+			resp, err := Fetch(RequestOptions{
+				URL:        "http://localhost:" + port + "/",
+				Proxy:      tt.scheme + "://" + tt.proxyUser + proxy.ln.Addr().String(),
+				NoDNSCache: true,
+			})
+			if tt.wantErr != "" {
+				if resp != nil {
+					resp.Body.Close()
+				}
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Fetch error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Fetch: %v", err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if string(body) != "via proxy" {
+				t.Errorf("body = %q, want %q", body, "via proxy")
+			}
+			proxy.mu.Lock()
+			defer proxy.mu.Unlock()
+			if len(proxy.targets) == 1 && tt.wantTarget == "ip" {
+				if host, p, _ := net.SplitHostPort(proxy.targets[0]); net.ParseIP(host) != nil && p == port {
+					return
+				}
+			}
+			if len(proxy.targets) != 1 || proxy.targets[0] != tt.wantTarget {
+				t.Errorf("proxy was asked for %v, want [%s]", proxy.targets, tt.wantTarget)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package network
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"mime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mclellac/hurl/config"
+)
+
+// Event is one Server-Sent Event. The fields hold what the event itself
+// carried; ID and Retry are empty if it had no "id:" or "retry:" line.
+type Event struct {
+	Type  string        // "event:" field; empty means the default "message"
+	Data  string        // "data:" lines joined with newlines
+	ID    string        // "id:" field
+	Retry time.Duration // "retry:" field
+}
+
+// IsEventStream reports whether contentType is text/event-stream.
+func IsEventStream(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && mediaType == "text/event-stream"
+}
+
+// SSEReader parses a text/event-stream body as it arrives, returning each
+// event once the blank line that ends it has been read.
+type SSEReader struct {
+	LastEventID string        // Latest "id:" value, to send as Last-Event-ID when reconnecting
+	Retry       time.Duration // Latest "retry:" value; 0 if the server sent none
+
+	r       *bufio.Reader
+	started bool // The first line has been read
+	afterCR bool // The last byte read was a CR, so a LF right after it is skipped
+}
+
+// NewSSEReader returns a reader for the event stream r.
+func NewSSEReader(r io.Reader) *SSEReader {
+	return &SSEReader{r: bufio.NewReader(r)}
+}
+
+// Next returns the next event. Events without data are not returned, as in
+// browsers, but their "id:" and "retry:" fields are still applied. At the
+// end of the stream an unfinished event is dropped and the error is io.EOF.
+func (s *SSEReader) Next() (Event, error) {
+	var ev Event
+	var data strings.Builder
+	hasData := false
+	for {
+		line, err := s.readLine()
+		if err != nil {
+			return Event{}, err
+		}
+		if line == "" {
+			if hasData {
+				ev.Data = strings.TrimSuffix(data.String(), "\n")
+				return ev, nil
+			}
+			ev = Event{}
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue // Comment, often sent as a keep-alive
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			ev.Type = value
+		case "data":
+			data.WriteString(value)
+			data.WriteByte('\n')
+			hasData = true
+		case "id":
+			if !strings.ContainsRune(value, 0) {
+				ev.ID = value
+				s.LastEventID = value
+			}
+		case "retry":
+			if ms, err := strconv.ParseUint(value, 10, 32); err == nil {
+				ev.Retry = time.Duration(ms) * time.Millisecond
+				s.Retry = ev.Retry
+			}
+		}
+	}
+}
+
+// readLine reads one line ended by CRLF, LF or CR, without the ending. A
+// UTF-8 byte order mark at the start of the stream is skipped.
+func (s *SSEReader) readLine() (string, error) {
+	var line strings.Builder
+	for {
+		c, err := s.r.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		afterCR := s.afterCR
+		s.afterCR = c == '\r'
+		switch {
+		case c == '\n' && afterCR && line.Len() == 0:
+			continue // The LF of a CRLF
+		case c == '\n', c == '\r':
+			return s.firstLine(line.String()), nil
+		}
+		line.WriteByte(c)
+	}
+}
+
+// firstLine strips the byte order mark from the stream's first line.
+func (s *SSEReader) firstLine(line string) string {
+	if !s.started {
+		s.started = true
+		line = strings.TrimPrefix(line, "\ufeff")
+	}
+	return line
+}
+
+// PrintEvent writes ev to w in the event-stream format it arrived in, with
+// the field names and values colored, followed by a blank line.
+func PrintEvent(w io.Writer, ev Event, cfg config.Config) {
+	keyColor := cfg.GetAnsiCode(cfg.HeaderKeyColor)
+	valueColor := cfg.GetAnsiCode(cfg.HeaderValueColor)
+	resetColor := cfg.ResetCode()
+	field := func(name, value string) {
+		fmt.Fprintf(w, "%s%s:%s %s%s%s\n", keyColor, name, resetColor, valueColor, value, resetColor)
+	}
+
+	if ev.Type != "" {
+		field("event", ev.Type)
+	}
+	if ev.ID != "" {
+		field("id", ev.ID)
+	}
+	if ev.Retry > 0 {
+		field("retry", strconv.FormatInt(ev.Retry.Milliseconds(), 10))
+	}
+	for _, line := range strings.Split(ev.Data, "\n") {
+		fmt.Fprintf(w, "%sdata:%s %s\n", keyColor, resetColor, line)
+	}
+	fmt.Fprintln(w)
+}
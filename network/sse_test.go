@@ -0,0 +1,72 @@
+package network
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mclellac/hurl/config"
+)
+
+func TestSSEReader(t *testing.T) {
+	stream := "\ufeff: keep-alive\n" +
+		"data: first\n\n" +
+		"event: update\r\nid: 7\r\ndata: line one\r\ndata:line two\r\n\r\n" +
+		"retry: 1500\rid: 8\r\r" + // No data, so no event, but the fields apply
+		"data\n\n" + // A field without a colon has an empty value
+		"id: \x00bad\ndata: keeps id 8\n\n" +
+		"data: unfinished"
+	r := NewSSEReader(strings.NewReader(stream))
+
+	want := []Event{
+		{Data: "first"},
+		{Type: "update", ID: "7", Data: "line one\nline two"},
+		{Data: ""},
+		{Data: "keeps id 8"},
+	}
+	var got []Event
+	for {
+		ev, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		got = append(got, ev)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("events = %+v, want %+v", got, want)
+	}
+	if r.LastEventID != "8" || r.Retry != 1500*time.Millisecond {
+		t.Errorf("LastEventID = %q, Retry = %s; want %q, 1.5s", r.LastEventID, r.Retry, "8")
+	}
+}
+
+func TestIsEventStream(t *testing.T) {
+	tests := map[string]bool{
+		"text/event-stream":                true,
+		"Text/Event-Stream; charset=utf-8": true,
+		"text/plain":                       false,
+		"":                                 false,
+	}
+	for contentType, want := range tests {
+		if got := IsEventStream(contentType); got != want {
+			t.Errorf("IsEventStream(%q) = %v, want %v", contentType, got, want)
+		}
+	}
+}
+
+func TestPrintEvent(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Color = false
+	var b strings.Builder
+	PrintEvent(&b, Event{Type: "update", ID: "7", Retry: 2 * time.Second, Data: "a\nb"}, cfg)
+	want := "event: update\nid: 7\nretry: 2000\ndata: a\ndata: b\n\n"
+	if b.String() != want {
+		t.Errorf("PrintEvent = %q, want %q", b.String(), want)
+	}
+}
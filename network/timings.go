@@ -0,0 +1,26 @@
+package network
+
+import "time"
+
+// Timings records how long each phase of a request took. When redirects are
+// followed, DNS, Connect and TLS accumulate across all hops.
+type Timings struct {
+	DNS       time.Duration // Time spent resolving host names
+	Connect   time.Duration // Time spent establishing TCP connections
+	TLS       time.Duration // Time spent in TLS handshakes
+	FirstByte time.Duration // From the start of the request until the first response byte
+	Total     time.Duration // From the start of the request until Finish (or until headers arrived)
+
+	start        time.Time
+	dnsStart     time.Time
+	connectStart time.Time
+	tlsStart     time.Time
+}
+
+// Finish records the total duration of the transfer. Call it after the
+// response body has been read so Total includes the download.
+func (t *Timings) Finish() {
+	if !t.start.IsZero() {
+		t.Total = time.Since(t.start)
+	}
+}
@@ -0,0 +1,98 @@
+package network
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/mclellac/hurl/config"
+)
+
+// traceLineWidth is the maximum number of bytes shown per line of a trace
+// dump; longer lines are wrapped.
+const traceLineWidth = 64
+
+// tracer writes a timestamped, plain-text dump of a transfer in the style of
+// curl's --trace-ascii. It is safe for concurrent use because request and
+// response bodies may be traced from different goroutines.
+type tracer struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// dump writes one event: a timestamped header line followed by data, shown
+// with hex offsets and with non-printable bytes replaced by '.'.
+func (t *tracer) dump(event string, data []byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	fmt.Fprintf(t.w, "%s %s, %d bytes (0x%x)\n", time.Now().Format("15:04:05.000000"), event, len(data), len(data))
+	for offset := 0; offset < len(data); {
+		line := data[offset:]
+		if i := bytes.IndexByte(line, '\n'); i >= 0 && i < traceLineWidth {
+			line = line[:i+1]
+		} else if len(line) > traceLineWidth {
+			line = line[:traceLineWidth]
+		}
+		fmt.Fprintf(t.w, "%04x: %s\n", offset, printable(bytes.TrimRight(line, "\r\n")))
+		offset += len(line)
+	}
+}
+
+// request dumps the request line and headers.
+func (t *tracer) request(req *http.Request, headers http.Header) {
+	var buf bytes.Buffer
+	printRequestVerbose(&buf, "", req, headers, plainConfig())
+	t.dump("=> Send header", buf.Bytes())
+}
+
+// response dumps the status line and headers.
+func (t *tracer) response(resp *http.Response) {
+	var buf bytes.Buffer
+	printResponseVerbose(&buf, "", resp, plainConfig())
+	t.dump("<= Recv header", buf.Bytes())
+}
+
+// body wraps rc so that everything read through it is dumped as event.
+func (t *tracer) body(rc io.ReadCloser, event string) io.ReadCloser {
+	return &tracedBody{ReadCloser: rc, t: t, event: event}
+}
+
+// tracedBody is a request or response body whose contents are traced as
+// they are read.
+type tracedBody struct {
+	io.ReadCloser
+	t     *tracer
+	event string
+}
+
+// Read reads from the underlying body and dumps what was read.
+func (b *tracedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.t.dump(b.event, p[:n])
+	}
+	return n, err
+}
+
+// plainConfig returns a configuration that formats output without colors.
+func plainConfig() config.Config {
+	cfg := config.DefaultConfig()
+	cfg.Color = false
+	return cfg
+}
+
+// printable replaces bytes outside printable ASCII with '.'.
+func printable(data []byte) string {
+	out := make([]byte, len(data))
+	for i, c := range data {
+		if c < ' ' || c > '~' {
+			c = '.'
+		}
+		out[i] = c
+	}
+	return string(out)
+}
@@ -0,0 +1,77 @@
+package network
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Values for RequestOptions.TraceTime.
+const (
+	TraceTimeRelative = "relative" // Seconds since the request started
+	TraceTimeAbsolute = "absolute" // Wall-clock time of day
+)
+
+// ParseTraceTime checks a --trace-time mode.
+func ParseTraceTime(mode string) (string, error) {
+	switch mode {
+	case TraceTimeRelative, TraceTimeAbsolute:
+		return mode, nil
+	}
+	return "", fmt.Errorf("invalid --trace-time %q (use relative or absolute)", mode)
+}
+
+// timestamped returns w with every line it is given prefixed by the time
+// in the given TraceTime mode, or w itself if mode is empty. Relative
+// times count from start.
+func timestamped(w io.Writer, mode string, start time.Time) io.Writer {
+	if mode == "" {
+		return w
+	}
+	return &timestampWriter{w: w, mode: mode, start: start}
+}
+
+// timestampWriter prefixes each line written through it with a timestamp.
+// It is safe for concurrent use, since connection attempts are traced from
+// several goroutines.
+type timestampWriter struct {
+	mu      sync.Mutex
+	w       io.Writer
+	mode    string
+	start   time.Time
+	midLine bool // The last write did not end with a newline
+}
+
+// Write writes p, starting each new line with the current time.
+func (t *timestampWriter) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	stamp := now.Format("15:04:05.000000")
+	if t.mode == TraceTimeRelative {
+		stamp = fmt.Sprintf("%10.6f", now.Sub(t.start).Seconds())
+	}
+	var buf bytes.Buffer
+	for rest := p; len(rest) > 0; {
+		if !t.midLine {
+			buf.WriteString(stamp)
+			buf.WriteByte(' ')
+		}
+		i := bytes.IndexByte(rest, '\n')
+		if i < 0 {
+			buf.Write(rest)
+			t.midLine = true
+			break
+		}
+		buf.Write(rest[:i+1])
+		rest = rest[i+1:]
+		t.midLine = false
+	}
+	if _, err := t.w.Write(buf.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
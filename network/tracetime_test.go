@@ -0,0 +1,63 @@
+package network
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTraceTime(t *testing.T) {
+	for _, mode := range []string{TraceTimeRelative, TraceTimeAbsolute} {
+		if got, err := ParseTraceTime(mode); err != nil || got != mode {
+			t.Errorf("ParseTraceTime(%q) = %q, %v", mode, got, err)
+		}
+	}
+	for _, mode := range []string{"", "Relative", "utc"} {
+		if _, err := ParseTraceTime(mode); err == nil {
+			t.Errorf("ParseTraceTime(%q) succeeded", mode)
+		}
+	}
+}
+
+func TestTimestampWriter(t *testing.T) {
+	tests := []struct {
+		mode  string
+		stamp string
+	}{
+		{TraceTimeRelative, `^ +\d+\.\d{6} `},
+		{TraceTimeAbsolute, `^\d\d:\d\d:\d\d\.\d{6} `},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			var b strings.Builder
+			w := timestamped(&b, tt.mode, time.Now())
+			// A line split across writes gets one timestamp; a write with
+			// several lines gets one per line.
+			for _, s := range []string{"* Connecting", " to host\n", "> GET / HTTP/1.1\n> Host: host\n"} {
+				if n, err := w.Write([]byte(s)); err != nil || n != len(s) {
+					t.Fatalf("Write(%q) = %d, %v", s, n, err)
+				}
+			}
+			lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+			want := []string{"* Connecting to host", "> GET / HTTP/1.1", "> Host: host"}
+			if len(lines) != len(want) {
+				t.Fatalf("output = %q, want %d lines", b.String(), len(want))
+			}
+			stamp := regexp.MustCompile(tt.stamp)
+			for i, line := range lines {
+				loc := stamp.FindStringIndex(line)
+				if loc == nil || line[loc[1]:] != want[i] {
+					t.Errorf("line %d = %q, want a timestamp and %q", i+1, line, want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestTimestampedWithoutMode(t *testing.T) {
+	var b strings.Builder
+	if w := timestamped(&b, "", time.Now()); w != &b {
+		t.Errorf("timestamped without a mode = %T, want the writer itself", w)
+	}
+}
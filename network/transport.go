@@ -0,0 +1,263 @@
+package network
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+// NewTransport builds the HTTP transport described by the connection
+// settings in opts: TLS versions and client certificate, HTTP version,
+// connect timeout, Unix socket and proxy. Sharing one transport between
+// several requests (via RequestOptions.Transport) lets them reuse
+// connections.
+func NewTransport(opts RequestOptions) (*http.Transport, error) {
+	valueColor := opts.Config.GetAnsiCode(opts.Config.HeaderValueColor)
+	traceColor := opts.Config.GetAnsiCode("white")
+	resetColor := opts.Config.ResetCode()
+
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	if tr.TLSClientConfig == nil {
+		tr.TLSClientConfig = &tls.Config{}
+	}
+	tr.TLSClientConfig.InsecureSkipVerify = opts.InsecureSkipTLS
+	tr.TLSClientConfig.MinVersion = opts.TLSMinVersion
+	tr.TLSClientConfig.MaxVersion = opts.TLSMaxVersion
+	if opts.SNI != "" {
+		// Also the name the server certificate is verified against.
+		tr.TLSClientConfig.ServerName = opts.SNI
+	}
+	if len(opts.PinnedPubKeys) > 0 {
+		tr.TLSClientConfig.VerifyConnection = verifyPinnedPubKey(opts.PinnedPubKeys)
+	}
+	// Without Compressed, keep the transport from asking for gzip and
+	// silently decoding it, so the body arrives exactly as sent.
+	tr.DisableCompression = true
+	if opts.ExpectTimeout > 0 {
+		tr.ExpectContinueTimeout = opts.ExpectTimeout
+	} else if opts.ExpectTimeout < 0 {
+		tr.ExpectContinueTimeout = 0
+	}
+
+	if opts.ClientCertFile != "" {
+		cert, err := loadClientCertificate(opts.ClientCertFile, opts.ClientKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		tr.TLSClientConfig.Certificates = append(tr.TLSClientConfig.Certificates, cert)
+		var diag io.Writer
+		if opts.Verbose >= VerboseTLS {
+			diag = timestamped(diagnostics(opts), opts.TraceTime, time.Now())
+		}
+		// Send the certificate only when it suits what the server asks for,
+		// as crypto/tls does for Certificates, and report it when it is sent.
+		tr.TLSClientConfig.GetClientCertificate = func(cri *tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			if cri.SupportsCertificate(&cert) != nil {
+				return &tls.Certificate{}, nil
+			}
+			if diag != nil && cert.Leaf != nil {
+				fmt.Fprintf(diag, "%s* Sending client certificate: %s%s%s\n", traceColor, valueColor, cert.Leaf.Subject.String(), resetColor)
+			}
+			return &cert, nil
+		}
+	}
+
+	switch opts.HTTPVersion {
+	case HTTPVersion11:
+		// A non-nil, empty TLSNextProto map turns off the transport's HTTP/2 support.
+		tr.ForceAttemptHTTP2 = false
+		tr.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+		tr.TLSClientConfig.NextProtos = []string{"http/1.1"}
+	case HTTPVersion2:
+		tr.ForceAttemptHTTP2 = true
+		tr.TLSClientConfig.NextProtos = []string{"h2", "http/1.1"}
+	}
+
+	// Use our own dialer so the connect timeout can be tuned independently of
+	// the overall client timeout. Whichever limit is reached first wins.
+	dialer := &net.Dialer{
+		Timeout:   connectTimeout(opts),
+		KeepAlive: defaultKeepAlive,
+	}
+	dialer.FallbackDelay = opts.FallbackDelay // Same meaning as in net.Dialer
+	if opts.KeepAliveTime > 0 {
+		dialer.KeepAlive = opts.KeepAliveTime
+	}
+	if opts.NoKeepAlive {
+		tr.DisableKeepAlives = true
+		dialer.KeepAlive = -1 // A negative value turns the probes off
+	}
+	if opts.Interface != "" {
+		if opts.UnixSocket != "" {
+			return nil, fmt.Errorf("--interface cannot be used with a Unix socket")
+		}
+		local, err := localBindAddr(opts.Interface)
+		if err != nil {
+			return nil, err
+		}
+		dialer.LocalAddr = local
+	}
+	// DoH replaces the system resolver and caches its own answers; otherwise
+	// host names are cached in front of the system resolver. Either way the
+	// dialer itself races the addresses it gets back.
+	tr.DialContext = dialer.DialContext
+	if opts.DoHURL != "" {
+		resolver, err := newResolver(opts.DoHURL, opts.NoDNSCache)
+		if err != nil {
+			return nil, err
+		}
+		dialer.Resolver = resolver
+	} else if !opts.NoDNSCache {
+		dialer.Resolver = newHostCache().resolver()
+	}
+	if opts.UnixSocket != "" {
+		// The URL still provides the path and Host header; only the dial target changes.
+		tr.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", opts.UnixSocket)
+		}
+	}
+
+	proxyURL, err := parseProxyURL(opts.Proxy)
+	if err != nil {
+		return nil, err
+	}
+	if opts.UnixSocket != "" {
+		// Every connection goes to the socket, so a proxy would never be reached.
+		tr.Proxy = nil
+	} else if proxyURL != nil && (proxyURL.Scheme == "socks5" || proxyURL.Scheme == "socks5h") {
+		// net/http always lets a SOCKS proxy resolve host names, so SOCKS
+		// is dialed here to resolve them locally for socks5://.
+		tr.Proxy = nil
+		tr.DialContext, err = socksDialContext(proxyURL, dialer)
+		if err != nil {
+			return nil, err
+		}
+	} else if proxyURL != nil {
+		// The transport turns credentials embedded in the proxy URL into a
+		// Proxy-Authorization header by itself.
+		tr.Proxy = http.ProxyURL(proxyURL)
+	} else {
+		tr.Proxy = http.ProxyFromEnvironment
+	}
+
+	if len(opts.ConnectTo) > 0 {
+		if opts.UnixSocket != "" {
+			return nil, fmt.Errorf("--connect-to cannot be used with a Unix socket")
+		}
+		if proxyURL != nil && (proxyURL.Scheme == "http" || proxyURL.Scheme == "https") {
+			// The transport dials the proxy, so the rules would never see the target.
+			return nil, fmt.Errorf("--connect-to cannot be used with an HTTP proxy")
+		}
+		dial := tr.DialContext
+		tr.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			target := connectTarget(opts.ConnectTo, addr)
+			if target != addr {
+				traceDial(ctx, "Connecting to %s instead of %s (--connect-to)", target, addr)
+			}
+			return dial(ctx, network, target)
+		}
+	}
+
+	return tr, nil
+}
+
+// connectTimeout returns the time limit for establishing a connection.
+func connectTimeout(opts RequestOptions) time.Duration {
+	if opts.ConnectTimeout > 0 {
+		return opts.ConnectTimeout
+	}
+	return defaultConnectTimeout
+}
+
+// localBindAddr resolves an --interface value, an IP address or the name of
+// a network interface, to the local address outgoing connections are bound
+// to. An interface's first IPv4 address is preferred. The address must
+// belong to this host.
+func localBindAddr(iface string) (*net.TCPAddr, error) {
+	if ip := net.ParseIP(iface); ip != nil {
+		addrs, err := net.InterfaceAddrs()
+		if err != nil {
+			return nil, fmt.Errorf("interface %q: %w", iface, err)
+		}
+		for _, a := range addrs {
+			if n, ok := a.(*net.IPNet); ok && n.IP.Equal(ip) {
+				return &net.TCPAddr{IP: ip}, nil
+			}
+		}
+		return nil, fmt.Errorf("interface %q: not an address of this host", iface)
+	}
+
+	ifi, err := net.InterfaceByName(iface)
+	if err != nil {
+		return nil, fmt.Errorf("interface %q: no such address or network interface", iface)
+	}
+	addrs, err := ifi.Addrs()
+	if err != nil {
+		return nil, fmt.Errorf("interface %q: %w", iface, err)
+	}
+	var found net.IP
+	for _, a := range addrs {
+		n, ok := a.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		if n.IP.To4() != nil {
+			return &net.TCPAddr{IP: n.IP}, nil
+		}
+		if found == nil {
+			found = n.IP
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("interface %q has no IP address", iface)
+	}
+	return &net.TCPAddr{IP: found}, nil
+}
+
+// defaultSOCKSPort is used for a SOCKS proxy URL without a port.
+const defaultSOCKSPort = "1080"
+
+// socksDialContext returns a DialContext function that connects through
+// the socks5 or socks5h proxy u, reaching the proxy with dialer and
+// authenticating with the user name and password in u, if any. For
+// socks5:// the target host name is resolved here and the proxy is given
+// an IP address; socks5h:// passes the name on for the proxy to resolve.
+func socksDialContext(u *url.URL, dialer *net.Dialer) (func(ctx context.Context, network, addr string) (net.Conn, error), error) {
+	proxyAddr := u.Host
+	if u.Port() == "" {
+		proxyAddr = net.JoinHostPort(u.Hostname(), defaultSOCKSPort)
+	}
+	var auth *proxy.Auth
+	if u.User != nil {
+		password, _ := u.User.Password()
+		auth = &proxy.Auth{User: u.User.Username(), Password: password}
+	}
+	d, err := proxy.SOCKS5("tcp", proxyAddr, auth, dialer)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SOCKS5 proxy %s: %w", proxyAddr, err)
+	}
+	socks := d.(proxy.ContextDialer)
+	remoteDNS := u.Scheme == "socks5h"
+
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if host, port, err := net.SplitHostPort(addr); err == nil && !remoteDNS && net.ParseIP(host) == nil {
+			resolver := dialer.Resolver
+			if resolver == nil {
+				resolver = net.DefaultResolver
+			}
+			ips, err := resolver.LookupIP(ctx, "ip", host)
+			if err != nil {
+				return nil, err
+			}
+			addr = net.JoinHostPort(ips[0].String(), port)
+		}
+		return socks.DialContext(ctx, network, addr)
+	}, nil
+}
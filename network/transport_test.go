@@ -0,0 +1,184 @@
+package network
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTLSVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		want    uint16
+	}{
+		{"", 0},
+		{"1.0", tls.VersionTLS10},
+		{"1.2", tls.VersionTLS12},
+		{"v1.3", tls.VersionTLS13},
+	}
+	for _, tt := range tests {
+		if got, err := ParseTLSVersion(tt.version); err != nil || got != tt.want {
+			t.Errorf("ParseTLSVersion(%q) = %#x, %v; want %#x", tt.version, got, err, tt.want)
+		}
+	}
+	for _, version := range []string{"1.4", "TLS1.2", "3"} {
+		if _, err := ParseTLSVersion(version); err == nil {
+			t.Errorf("ParseTLSVersion(%q) succeeded", version)
+		}
+	}
+}
+
+func TestTLSMinVersion(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.TLS = &tls.Config{MaxVersion: tls.VersionTLS12}
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0) // The refused handshake is expected
+	srv.StartTLS()
+	defer srv.Close()
+
+	resp, err := Fetch(RequestOptions{URL: srv.URL, InsecureSkipTLS: true})
+	if err != nil {
+		t.Fatalf("Fetch without a minimum version: %v", err)
+	}
+	resp.Body.Close()
+	if resp.TLS == nil || resp.TLS.Version != tls.VersionTLS12 {
+		t.Errorf("negotiated %v, want TLS 1.2", resp.TLS)
+	}
+
+	resp, err = Fetch(RequestOptions{URL: srv.URL, InsecureSkipTLS: true, TLSMinVersion: tls.VersionTLS13})
+	if resp != nil {
+		resp.Body.Close()
+	}
+	if err == nil || !strings.Contains(err.Error(), "protocol version") {
+		t.Errorf("Fetch with a TLS 1.3 minimum against a TLS 1.2 server error = %v, want a protocol version failure", err)
+	}
+}
+
+func TestHTTPVersion(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	defer srv.Close()
+
+	tests := []struct {
+		version string
+		want    int
+	}{
+		{"", 2},
+		{HTTPVersion11, 1},
+		{HTTPVersion2, 2},
+	}
+	for _, tt := range tests {
+		resp, err := Fetch(RequestOptions{URL: srv.URL, InsecureSkipTLS: true, HTTPVersion: tt.version})
+		if err != nil {
+			t.Fatalf("Fetch with HTTPVersion %q: %v", tt.version, err)
+		}
+		resp.Body.Close()
+		if resp.ProtoMajor != tt.want {
+			t.Errorf("HTTPVersion %q negotiated %s, want HTTP/%d", tt.version, resp.Proto, tt.want)
+		}
+	}
+
+	// Plain http:// has no ALPN, so HTTP/2 falls back to HTTP/1.1.
+	plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer plain.Close()
+	resp, err := Fetch(RequestOptions{URL: plain.URL, HTTPVersion: HTTPVersion2})
+	if err != nil {
+		t.Fatalf("Fetch over plain HTTP: %v", err)
+	}
+	resp.Body.Close()
+	if resp.ProtoMajor != 1 {
+		t.Errorf("plain HTTP with HTTPVersion 2 used %s, want HTTP/1.1", resp.Proto)
+	}
+}
+
+// writeClientCert writes a self-signed client certificate and its key to a
+// PEM file in dir and returns its path and the certificate.
+func writeClientCert(t *testing.T, dir, name string) (string, *x509.Certificate) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	data = append(data, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})...)
+	path := filepath.Join(dir, name+".pem")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path, cert
+}
+
+func TestClientCertificate(t *testing.T) {
+	dir := t.TempDir()
+	trusted, trustedCert := writeClientCert(t, dir, "trusted")
+	other, _ := writeClientCert(t, dir, "other")
+
+	pool := x509.NewCertPool()
+	pool.AddCert(trustedCert)
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) > 0 {
+			io.WriteString(w, r.TLS.PeerCertificates[0].Subject.CommonName)
+		}
+	}))
+	srv.TLS = &tls.Config{ClientAuth: tls.VerifyClientCertIfGiven, ClientCAs: pool}
+	srv.StartTLS()
+	defer srv.Close()
+
+	tests := []struct {
+		cert string
+		want string
+	}{
+		{trusted, "trusted"},
+		{other, ""}, // Not issued by a CA the server accepts, so not sent
+	}
+	for _, tt := range tests {
+		// The verbosity must not change which certificate is sent.
+		for _, verbose := range []int{0, VerboseTLS} {
+			resp, err := Fetch(RequestOptions{URL: srv.URL, InsecureSkipTLS: true, ClientCertFile: tt.cert, Verbose: verbose, Diagnostics: io.Discard})
+			if err != nil {
+				t.Fatalf("Fetch with %s at verbosity %d: %v", filepath.Base(tt.cert), verbose, err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if string(body) != tt.want {
+				t.Errorf("%s at verbosity %d: server saw client certificate %q, want %q", filepath.Base(tt.cert), verbose, body, tt.want)
+			}
+		}
+	}
+}
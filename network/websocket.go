@@ -0,0 +1,148 @@
+package network
+
+import (
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// webSocketGUID is appended to the key to compute Sec-WebSocket-Accept
+// (RFC 6455, section 1.3).
+const webSocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// NewWebSocketKey returns a random Sec-WebSocket-Key: 16 bytes, base64
+// encoded.
+func NewWebSocketKey() (string, error) {
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// WebSocketAccept returns the Sec-WebSocket-Accept value a server must
+// answer key with.
+func WebSocketAccept(key string) string {
+	sum := sha1.Sum([]byte(key + webSocketGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// WebSocketHeaders returns the headers of a WebSocket opening handshake
+// with the given key, in "Key: Value" format.
+func WebSocketHeaders(key string) []string {
+	return []string{
+		"Connection: Upgrade",
+		"Upgrade: websocket",
+		"Sec-WebSocket-Version: 13",
+		"Sec-WebSocket-Key: " + key,
+	}
+}
+
+// CheckWebSocketUpgrade reports why resp does not complete the WebSocket
+// handshake of the request it answers, or nil if it does: the status must
+// be 101 with "Upgrade: websocket" and "Connection: Upgrade", the
+// Sec-WebSocket-Accept value must match the key that was sent, and any
+// subprotocol must be one the request offered.
+func CheckWebSocketUpgrade(resp *http.Response) error {
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		return fmt.Errorf("the server answered %s instead of 101 Switching Protocols", resp.Status)
+	}
+	if !headerHasToken(resp.Header, "Upgrade", "websocket") {
+		return fmt.Errorf("the response upgrades to %q, not websocket", resp.Header.Get("Upgrade"))
+	}
+	if !headerHasToken(resp.Header, "Connection", "upgrade") {
+		return fmt.Errorf("the response has no \"Connection: Upgrade\" header")
+	}
+	if resp.Request == nil {
+		return nil
+	}
+	key := resp.Request.Header.Get("Sec-WebSocket-Key")
+	if got, want := resp.Header.Get("Sec-WebSocket-Accept"), WebSocketAccept(key); got != want {
+		return fmt.Errorf("Sec-WebSocket-Accept is %q, expected %q for key %q", got, want, key)
+	}
+	if protocol := resp.Header.Get("Sec-WebSocket-Protocol"); protocol != "" && !headerHasToken(resp.Request.Header, "Sec-WebSocket-Protocol", protocol) {
+		return fmt.Errorf("the server chose subprotocol %q, which was not offered", protocol)
+	}
+	return nil
+}
+
+// headerHasToken reports whether the comma-separated values of header key
+// include token, ignoring case.
+func headerHasToken(h http.Header, key, token string) bool {
+	for _, value := range h.Values(key) {
+		for _, item := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(item), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CloseWebSocket ends an upgraded WebSocket connection cleanly: it sends a
+// close frame with status 1000 (normal closure), waits up to timeout for
+// the server's close frame and then closes conn.
+func CloseWebSocket(conn io.ReadWriteCloser, timeout time.Duration) error {
+	// A client frame is masked; the payload is the 2-byte status code.
+	var mask [4]byte
+	if _, err := rand.Read(mask[:]); err != nil {
+		conn.Close()
+		return err
+	}
+	payload := []byte{0x03, 0xe8}
+	frame := []byte{0x88, 0x80 | byte(len(payload))}
+	frame = append(frame, mask[:]...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	if _, err := conn.Write(frame); err != nil {
+		conn.Close()
+		return fmt.Errorf("error sending WebSocket close frame: %w", err)
+	}
+
+	// conn has no deadlines, so the read is abandoned by closing it.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		var header [2]byte
+		for {
+			if _, err := io.ReadFull(conn, header[:]); err != nil {
+				return
+			}
+			if header[0]&0x0f == 0x8 {
+				return // The server's close frame
+			}
+			// Skip any other frame: its extended length, mask key and payload.
+			length := uint64(header[1] & 0x7f)
+			if length >= 126 {
+				ext := make([]byte, 2)
+				if length == 127 {
+					ext = make([]byte, 8)
+				}
+				if _, err := io.ReadFull(conn, ext); err != nil {
+					return
+				}
+				length = 0
+				for _, b := range ext {
+					length = length<<8 | uint64(b)
+				}
+			}
+			if header[1]&0x80 != 0 {
+				length += 4
+			}
+			if _, err := io.CopyN(io.Discard, conn, int64(length)); err != nil {
+				return
+			}
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+	}
+	return conn.Close()
+}
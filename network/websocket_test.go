@@ -0,0 +1,156 @@
+package network
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// upgradeServer answers a WebSocket handshake with 101 and a
+// Content-Encoding header, then echoes what the client writes.
+func upgradeServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Errorf("Hijack: %v", err)
+			return
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\n" +
+			"Upgrade: websocket\r\nConnection: Upgrade\r\nContent-Encoding: gzip\r\n" +
+			"Sec-WebSocket-Accept: " + WebSocketAccept(r.Header.Get("Sec-WebSocket-Key")) + "\r\n\r\n")
+		rw.Flush()
+		line, _ := bufio.NewReader(rw).ReadString('\n')
+		conn.Write([]byte(line))
+	}))
+}
+
+func TestFetchUpgradeKeepsConnectionWritable(t *testing.T) {
+	srv := upgradeServer(t)
+	defer srv.Close()
+	key, err := NewWebSocketKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := Fetch(RequestOptions{
+		URL:            srv.URL,
+		DefaultHeaders: WebSocketHeaders(key),
+		Compressed:     true,
+		MaxFileSize:    1,
+		RateLimit:      1000,
+	})
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	defer resp.Body.Close()
+	if err := CheckWebSocketUpgrade(resp); err != nil {
+		t.Errorf("CheckWebSocketUpgrade: %v", err)
+	}
+	conn, ok := resp.Body.(io.ReadWriteCloser)
+	if !ok {
+		t.Fatalf("body is %T, not an io.ReadWriteCloser", resp.Body)
+	}
+	if _, err := io.WriteString(conn, "ping\n"); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	echo, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil || echo != "ping\n" {
+		t.Errorf("echo = %q, %v; want %q", echo, err, "ping\n")
+	}
+}
+
+func TestWebSocketAccept(t *testing.T) {
+	// The example handshake of RFC 6455, section 1.3.
+	if got, want := WebSocketAccept("dGhlIHNhbXBsZSBub25jZQ=="), "s3pPLMBiTxaQ9kYGzzhZRbK+xOo="; got != want {
+		t.Errorf("WebSocketAccept = %q, want %q", got, want)
+	}
+}
+
+func TestNewWebSocketKey(t *testing.T) {
+	key, err := NewWebSocketKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := base64.StdEncoding.DecodeString(key)
+	if err != nil || len(raw) != 16 {
+		t.Errorf("key %q decodes to %d bytes (%v), want 16", key, len(raw), err)
+	}
+}
+
+func TestCheckWebSocketUpgrade(t *testing.T) {
+	const key = "dGhlIHNhbXBsZSBub25jZQ=="
+	request := &http.Request{Header: http.Header{
+		"Sec-Websocket-Key":      {key},
+		"Sec-Websocket-Protocol": {"chat, superchat"},
+	}}
+	response := func(status int, headers ...string) *http.Response {
+		resp := &http.Response{StatusCode: status, Status: fmt.Sprintf("%d %s", status, http.StatusText(status)), Header: http.Header{}, Request: request}
+		for i := 0; i < len(headers); i += 2 {
+			resp.Header.Add(headers[i], headers[i+1])
+		}
+		return resp
+	}
+	valid := []string{"Upgrade", "WebSocket", "Connection", "keep-alive, Upgrade", "Sec-WebSocket-Accept", WebSocketAccept(key)}
+
+	tests := []struct {
+		name    string
+		resp    *http.Response
+		wantErr string
+	}{
+		{"accepted", response(101, valid...), ""},
+		{"accepted with an offered subprotocol", response(101, append(valid, "Sec-WebSocket-Protocol", "superchat")...), ""},
+		{"not 101", response(200), "the server answered 200 OK"},
+		{"wrong upgrade", response(101, "Upgrade", "h2c", "Connection", "Upgrade"), "not websocket"},
+		{"no connection header", response(101, "Upgrade", "websocket"), "Connection: Upgrade"},
+		{"wrong accept", response(101, "Upgrade", "websocket", "Connection", "Upgrade", "Sec-WebSocket-Accept", "bogus"), "Sec-WebSocket-Accept is \"bogus\""},
+		{"unoffered subprotocol", response(101, append(valid, "Sec-WebSocket-Protocol", "mqtt")...), "not offered"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckWebSocketUpgrade(tt.resp)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("CheckWebSocketUpgrade: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("CheckWebSocketUpgrade error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCloseWebSocket(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+	received := make(chan []byte, 1)
+	go func() {
+		frame := make([]byte, 8)
+		io.ReadFull(server, frame)
+		received <- frame
+		// A text frame before the close frame is skipped.
+		server.Write([]byte{0x81, 2, 'h', 'i', 0x88, 2, 0x03, 0xe8})
+	}()
+
+	start := time.Now()
+	if err := CloseWebSocket(client, 5*time.Second); err != nil {
+		t.Fatalf("CloseWebSocket: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("CloseWebSocket took %s; it should return once the server's close frame arrives", elapsed)
+	}
+	frame := <-received
+	if frame[0] != 0x88 || frame[1] != 0x82 {
+		t.Fatalf("frame header = % x, want a masked close frame with a 2-byte payload", frame[:2])
+	}
+	mask := frame[2:6]
+	if status := []byte{frame[6] ^ mask[0], frame[7] ^ mask[1]}; status[0] != 0x03 || status[1] != 0xe8 {
+		t.Errorf("close status = % x, want 03 e8 (1000)", status)
+	}
+}
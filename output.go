@@ -0,0 +1,110 @@
+package main
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mclellac/hurl/config"
+	"github.com/mclellac/hurl/display"
+	"github.com/mclellac/hurl/network"
+)
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+// Read reads from the underlying reader and records how much was read.
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// writeHead writes the status line and headers of resp to w, followed by the
+// blank line that separates them from the body.
+func writeHead(w io.Writer, resp *http.Response, cfg config.Config) {
+	valueColor := cfg.GetAnsiCode(cfg.HeaderValueColor)
+	resetColor := cfg.ResetCode()
+	statusCode, statusText, _ := strings.Cut(resp.Status, " ")
+	fmt.Fprintf(w, "%s%s%s %s%s%s %s%s%s\n",
+		valueColor, resp.Proto, resetColor,
+		cfg.StatusColor(resp.StatusCode), statusCode, resetColor,
+		valueColor, statusText, resetColor)
+
+	display.PrintHeaders(w, resp.Header, cfg)
+	fmt.Fprintln(w)
+}
+
+// writeBody writes the response body to w. JSON bodies are pretty-printed
+// when pretty is set; everything else is copied byte-for-byte so redirecting
+// to a file keeps binary content intact. It returns the size of the body as
+// received, which pretty-printing does not change.
+func writeBody(w io.Writer, resp *http.Response, pretty bool, cfg config.Config) (int64, error) {
+	body := &countingReader{r: resp.Body}
+	if pretty && display.IsJSONContentType(resp.Header.Get("Content-Type")) {
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return body.n, err
+		}
+		display.PrintJSON(w, data, cfg)
+		return body.n, nil
+	}
+	_, err := io.Copy(w, body)
+	return body.n, err
+}
+
+// resumeOffset resolves a -C/--continue-at value: a byte offset, or "-" to
+// continue after the bytes already in the output file (0 if it is missing).
+func resumeOffset(value, output string) (int64, error) {
+	if value != "-" {
+		offset, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || offset < 0 {
+			return 0, fmt.Errorf("invalid offset %q (expected a byte count or \"-\")", value)
+		}
+		return offset, nil
+	}
+
+	if output == "" {
+		return 0, fmt.Errorf("\"-\" requires --output")
+	}
+	fi, err := os.Stat(output)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return fi.Size(), nil
+}
+
+// saveCertificate writes the leaf of a server's certificate chain to path
+// as PEM, for --cert-out.
+func saveCertificate(path string, certs []*x509.Certificate) error {
+	if len(certs) == 0 {
+		return errors.New("the server sent no certificate (is the URL https://?)")
+	}
+	return os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certs[0].Raw}), 0644)
+}
+
+// warnCertExpiry warns on o.Stderr if cert expires within days, in yellow,
+// or has already expired, in red.
+func warnCertExpiry(o outputOptions, cert *x509.Certificate, days int) {
+	left := network.DaysUntilExpiry(cert)
+	expiry := cert.NotAfter.Format(time.RFC1123)
+	switch {
+	case time.Now().After(cert.NotAfter):
+		fmt.Fprintf(o.Stderr, "%sWarning: the server certificate expired %d day(s) ago, on %s%s\n", o.Err.GetAnsiCode("red"), -left, expiry, o.Err.ResetCode())
+	case left < days:
+		fmt.Fprintf(o.Stderr, "%sWarning: the server certificate expires in %d day(s), on %s%s\n", o.Err.GetAnsiCode("yellow"), left, expiry, o.Err.ResetCode())
+	}
+}
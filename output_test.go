@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mclellac/hurl/config"
+	"github.com/mclellac/hurl/network"
+)
+
+func TestWarnCertExpiry(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Color = false
+	tests := []struct {
+		name string
+		left time.Duration
+		days int
+		want string
+	}{
+		{"far off", 90*24*time.Hour + time.Hour, 30, ""},
+		{"within the warning", 10*24*time.Hour + time.Hour, 30, "Warning: the server certificate expires in 10 day(s)"},
+		{"warning disabled by a smaller limit", 10*24*time.Hour + time.Hour, 5, ""},
+		{"expired", -3*24*time.Hour - time.Hour, 30, "Warning: the server certificate expired 3 day(s) ago"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stderr strings.Builder
+			cert := &x509.Certificate{NotAfter: time.Now().Add(tt.left)}
+			warnCertExpiry(outputOptions{Err: cfg, Stderr: &stderr}, cert, tt.days)
+			if tt.want == "" && stderr.Len() != 0 {
+				t.Errorf("warned %q, want nothing", stderr.String())
+			}
+			if !strings.Contains(stderr.String(), tt.want) {
+				t.Errorf("warned %q, want %q", stderr.String(), tt.want)
+			}
+		})
+	}
+}
+
+// expiringServer starts a TLS server whose self-signed certificate expires
+// at notAfter.
+func expiringServer(t *testing.T, notAfter time.Time) *httptest.Server {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		NotBefore:    time.Now().Add(-30 * 24 * time.Hour),
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.TLS = &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestTransferCertExpiry(t *testing.T) {
+	soon := expiringServer(t, time.Now().Add(5*24*time.Hour+time.Hour))
+	expired := expiringServer(t, time.Now().Add(-2*24*time.Hour-time.Hour))
+	colored := config.DefaultConfig()
+	colored.Color = true
+
+	tests := []struct {
+		name     string
+		url      string
+		check    bool
+		days     int
+		wantWarn string
+		wantDays string
+	}{
+		{"within the warning", soon.URL, true, 30, config.ColorYellow + "Warning: the server certificate expires in 5 day(s)", "5"},
+		{"not checked", soon.URL, false, 30, "", "5"},
+		{"expired", expired.URL, true, 30, config.ColorRed + "Warning: the server certificate expired 2 day(s) ago", "-2"},
+		// An explicit 0 turns off the early warning, but not the expired one.
+		{"expired with 0 days", expired.URL, true, 0, config.ColorRed + "Warning: the server certificate expired 2 day(s) ago", "-2"},
+		{"soon with 0 days", soon.URL, true, 0, "", "5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr strings.Builder
+			o := testOutput(&stdout, &stderr)
+			o.Err = colored
+			o.CertCheck = tt.check
+			o.CertWarnDays = tt.days
+			o.WriteOut = "%{cert_expiry_days}"
+			opts := network.RequestOptions{URL: tt.url, InsecureSkipTLS: true}
+			if code := transfer(context.Background(), opts, "", o); code != 0 {
+				t.Fatalf("transfer = %d, stderr %q", code, stderr.String())
+			}
+			if tt.wantWarn == "" && stderr.Len() != 0 {
+				t.Errorf("stderr = %q, want no warning", stderr.String())
+			}
+			if !strings.HasPrefix(stderr.String(), tt.wantWarn) {
+				t.Errorf("stderr = %q, want %q", stderr.String(), tt.wantWarn)
+			}
+			if stdout.String() != tt.wantDays {
+				t.Errorf("%%{cert_expiry_days} = %q, want %q", stdout.String(), tt.wantDays)
+			}
+		})
+	}
+}
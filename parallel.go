@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// fetchParallel runs fetch for n transfers using at most max goroutines.
+// Each transfer writes into its own buffers, which are copied to o.Stdout,
+// o.Stderr and the diagnostics and trace files in input order as soon as every
+// earlier transfer has been flushed, so the output of different URLs never
+// interleaves. Exit codes are stored in codes.
+func fetchParallel(n, max int, o outputOptions, fetch func(int, outputOptions) int, codes []int) {
+	type result struct {
+		stdout, stderr bytes.Buffer
+		diag, trace    bytes.Buffer
+		done           chan struct{}
+	}
+	results := make([]*result, n)
+	for i := range results {
+		results[i] = &result{done: make(chan struct{})}
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(max, n) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				r := results[i]
+				buffered := o
+				buffered.Stdout = &r.stdout
+				buffered.Stderr = &r.stderr
+				// A file gets its own buffer; stderr ("-") keeps its place
+				// among the other messages.
+				buffered.Diagnostics = bufferFor(o.Diagnostics, o.Stderr, &r.diag, &r.stderr)
+				buffered.Trace = bufferFor(o.Trace, o.Stderr, &r.trace, &r.stderr)
+				codes[i] = fetch(i, buffered)
+				close(r.done)
+			}
+		}()
+	}
+	go func() {
+		for i := range n {
+			jobs <- i
+		}
+		close(jobs)
+	}()
+
+	for _, r := range results {
+		<-r.done
+		o.Stdout.Write(r.stdout.Bytes())
+		o.Stderr.Write(r.stderr.Bytes())
+		if o.Diagnostics != nil && o.Diagnostics != o.Stderr {
+			o.Diagnostics.Write(r.diag.Bytes())
+		}
+		if o.Trace != nil && o.Trace != o.Stderr {
+			o.Trace.Write(r.trace.Bytes())
+		}
+	}
+	wg.Wait()
+}
+
+// bufferFor returns the buffer that replaces w for one parallel transfer:
+// nil if w is, stderrBuf if w is stderr, and own otherwise.
+func bufferFor(w, stderr io.Writer, own, stderrBuf *bytes.Buffer) io.Writer {
+	switch w {
+	case nil:
+		return nil
+	case stderr:
+		return stderrBuf
+	}
+	return own
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFetchParallelKeepsOutputInOrder(t *testing.T) {
+	var stdout, stderr, diag, trace strings.Builder
+	o := testOutput(&stdout, &stderr)
+	o.Diagnostics = &diag
+	o.Trace = &trace
+
+	// Later transfers finish first, and each writes two lines to every
+	// stream with a pause between them.
+	const n = 4
+	codes := make([]int, n)
+	fetchParallel(n, n, o, func(i int, o outputOptions) int {
+		time.Sleep(time.Duration(n-i) * 5 * time.Millisecond)
+		for _, part := range []string{"a", "b"} {
+			fmt.Fprintf(o.Stdout, "out %d%s\n", i, part)
+			fmt.Fprintf(o.Stderr, "err %d%s\n", i, part)
+			fmt.Fprintf(o.Diagnostics, "diag %d%s\n", i, part)
+			fmt.Fprintf(o.Trace, "trace %d%s\n", i, part)
+			time.Sleep(time.Millisecond)
+		}
+		return i
+	}, codes)
+
+	for name, got := range map[string]string{"out": stdout.String(), "err": stderr.String(), "diag": diag.String(), "trace": trace.String()} {
+		var want strings.Builder
+		for i := range n {
+			fmt.Fprintf(&want, "%s %da\n%s %db\n", name, i, name, i)
+		}
+		if got != want.String() {
+			t.Errorf("%s = %q, want %q", name, got, want.String())
+		}
+	}
+	for i, code := range codes {
+		if code != i {
+			t.Errorf("codes[%d] = %d, want %d", i, code, i)
+		}
+	}
+}
+
+func TestFetchParallelTraceToStderr(t *testing.T) {
+	var stdout, stderr strings.Builder
+	o := testOutput(&stdout, &stderr)
+	o.Trace = o.Stderr // --trace-ascii -
+
+	fetchParallel(2, 2, o, func(i int, o outputOptions) int {
+		fmt.Fprintf(o.Trace, "trace %d\n", i)
+		fmt.Fprintf(o.Stderr, "err %d\n", i)
+		return 0
+	}, make([]int, 2))
+	if want := "trace 0\nerr 0\ntrace 1\nerr 1\n"; stderr.String() != want {
+		t.Errorf("stderr = %q, want %q", stderr.String(), want)
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"mime"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// urlFileName returns the file name -O/--remote-name saves rawURL as: the
+// last segment of its path, or "" if the path is empty or ends in '/'.
+func urlFileName(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Path == "" || strings.HasSuffix(u.Path, "/") {
+		return ""
+	}
+	return safeFileName(path.Base(u.Path))
+}
+
+// dispositionFileName returns the file name suggested by a
+// Content-Disposition header, including the RFC 5987 filename* form, or ""
+// if there is none.
+func dispositionFileName(header string) string {
+	if header == "" {
+		return ""
+	}
+	_, params, err := mime.ParseMediaType(header)
+	if err != nil {
+		return ""
+	}
+	return safeFileName(params["filename"])
+}
+
+// safeFileName reduces a name taken from a URL or a response header to a
+// plain file name in the current directory, so a server cannot make hurl
+// write elsewhere: any directory part is dropped, and "", "." and ".." are
+// rejected by returning "".
+func safeFileName(name string) string {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	if name == "." || name == ".." || name == "/" || strings.ContainsRune(name, 0) {
+		return ""
+	}
+	return name
+}
@@ -0,0 +1,49 @@
+package main
+
+import "testing"
+
+func TestDispositionFileName(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", ""},
+		{"inline", ""},
+		{"attachment; filename=report.pdf", "report.pdf"},
+		{`attachment; filename="annual report.pdf"`, "annual report.pdf"},
+		{`attachment; filename="quote\"d.txt"`, `quote"d.txt`},
+		{"attachment; filename*=UTF-8''na%C3%AFve%20file.txt", "naïve file.txt"},
+		{`attachment; filename="fallback.txt"; filename*=UTF-8''r%C3%A9sum%C3%A9.txt`, "résumé.txt"},
+		{`attachment; filename="../../etc/passwd"`, "passwd"},
+		{`attachment; filename="/etc/passwd"`, "passwd"},
+		{`attachment; filename="..\..\boot.ini"`, "boot.ini"},
+		{`attachment; filename=".."`, ""},
+		{`attachment; filename="dir/"`, "dir"},
+		{"attachment; filename=", ""},
+		{"attachment; filename", ""},
+	}
+	for _, tt := range tests {
+		if got := dispositionFileName(tt.header); got != tt.want {
+			t.Errorf("dispositionFileName(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestURLFileName(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://example.com/files/report.pdf", "report.pdf"},
+		{"https://example.com/files/report.pdf?download=1", "report.pdf"},
+		{"https://example.com/a%20b.txt", "a b.txt"},
+		{"https://example.com/files/", ""},
+		{"https://example.com", ""},
+		{"https://example.com/..", ""},
+	}
+	for _, tt := range tests {
+		if got := urlFileName(tt.url); got != tt.want {
+			t.Errorf("urlFileName(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
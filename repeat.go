@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/mclellac/hurl/display"
+	"github.com/mclellac/hurl/network"
+)
+
+// benchmark sends the request built by prepare n times, waiting delay
+// between requests, and prints a table row per request followed by a
+// summary of the response times. Bodies are read and discarded so the times
+// include the download. The shared transport keeps connections alive
+// between requests. With o.Metrics, each request's timings are also
+// written to the --metrics-out file.
+func benchmark(ctx context.Context, n int, delay time.Duration, o outputOptions, prepare func() (network.RequestOptions, error)) int {
+	display.PrintBenchmarkHeader(o.Stdout, o.Out)
+
+	var times []time.Duration
+	failed := 0
+	exitCode := 0
+	for i := 1; i <= n; i++ {
+		if i > 1 && delay > 0 {
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+			}
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		opts, err := prepare()
+		if err != nil {
+			o.errorf("Error: %v", err)
+			return 1
+		}
+		result, err := network.DoContext(ctx, opts)
+		var size int64
+		if result != nil {
+			if err == nil {
+				size, err = io.Copy(io.Discard, result.Response.Body)
+			}
+			result.Response.Body.Close()
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		if err != nil {
+			o.errorf("Request %d failed: %v", i, err)
+			failed++
+			exitCode = 1
+			if o.Metrics != nil {
+				if err := o.Metrics.record(opts.URL, i, 0, network.Timings{}, 0); err != nil {
+					o.errorf("Error: --metrics-out: %v", err)
+					return 1
+				}
+			}
+			continue
+		}
+
+		result.Timings.Finish()
+		status := result.Response.StatusCode
+		if o.Metrics != nil {
+			if err := o.Metrics.record(opts.URL, i, status, result.Timings, size); err != nil {
+				o.errorf("Error: --metrics-out: %v", err)
+				return 1
+			}
+		}
+		display.PrintBenchmarkRow(o.Stdout, i, status, size, result.Timings.Total, o.Out)
+		times = append(times, result.Timings.Total)
+		if o.Fail && status >= 400 {
+			exitCode = exitHTTPError
+		}
+	}
+
+	display.PrintBenchmarkSummary(o.Stdout, times, failed, o.Out)
+	if ctx.Err() != nil {
+		o.errorf("Transfer interrupted")
+		return exitInterrupted
+	}
+	return exitCode
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+)
+
+// mergeFileHeaders returns the headers of a --file request followed by the
+// -H headers, leaving out file headers whose name is also given with -H so
+// that the command line replaces them rather than adding to them. Both win
+// over the config file's default headers.
+func mergeFileHeaders(file, custom []string) []string {
+	headerKey := func(h string) string {
+		key, _, _ := strings.Cut(h, ":")
+		return http.CanonicalHeaderKey(strings.TrimRight(strings.TrimSpace(key), ";"))
+	}
+	given := make(map[string]bool)
+	for _, h := range custom {
+		given[headerKey(h)] = true
+	}
+	var merged []string
+	for _, h := range file {
+		if !given[headerKey(h)] {
+			merged = append(merged, h)
+		}
+	}
+	return append(merged, custom...)
+}
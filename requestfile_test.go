@@ -0,0 +1,18 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeFileHeaders(t *testing.T) {
+	file := []string{"Accept: application/json", "X-Trace: file", "Authorization: Bearer file"}
+	custom := []string{"x-trace: cli", "Authorization;"}
+	want := []string{"Accept: application/json", "x-trace: cli", "Authorization;"}
+	if got := mergeFileHeaders(file, custom); !reflect.DeepEqual(got, want) {
+		t.Errorf("mergeFileHeaders = %q, want %q", got, want)
+	}
+	if got := mergeFileHeaders(file, nil); !reflect.DeepEqual(got, file) {
+		t.Errorf("mergeFileHeaders without -H = %q, want the file headers", got)
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/mclellac/hurl/network"
+)
+
+// defaultSSERetry is how long --sse waits before reconnecting when the
+// server has not sent a "retry:" field, as in browsers.
+const defaultSSERetry = 3 * time.Second
+
+// streamEvents sends the request built by prepare for a Server-Sent Events
+// stream and prints each event as it arrives. When the connection drops it
+// reconnects after the server's retry delay, sending the last event ID as
+// Last-Event-ID. The request's Timeout (--max-time) bounds the whole
+// session, reconnections included, and reaching it ends the stream
+// normally. A first request that fails, even with a response, or any
+// response that is not a 200 event stream, is an error.
+func streamEvents(ctx context.Context, o outputOptions, prepare func() (network.RequestOptions, error)) int {
+	streamCtx, maxTime := ctx, time.Duration(0)
+	retry := defaultSSERetry
+	lastID := ""
+
+	for attempt := 1; ; attempt++ {
+		opts, err := prepare()
+		if err != nil {
+			o.errorf("Error: %v", err)
+			return 1
+		}
+		if attempt == 1 && opts.Timeout > 0 {
+			maxTime = opts.Timeout
+			var cancel context.CancelFunc
+			streamCtx, cancel = context.WithTimeout(ctx, maxTime)
+			defer cancel()
+		}
+		opts.Timeout = 0
+		if opts.Accept == "" {
+			opts.Accept = "text/event-stream"
+		}
+		opts.DefaultHeaders = append(slices.Clip(opts.DefaultHeaders), "Cache-Control: no-cache")
+		if lastID != "" {
+			opts.DefaultHeaders = append(opts.DefaultHeaders, "Last-Event-ID: "+lastID)
+		}
+		result, err := network.DoContext(streamCtx, opts)
+		requestErr := err
+		if result != nil && err == nil {
+			resp := result.Response
+			if resp.StatusCode != http.StatusOK || !network.IsEventStream(resp.Header.Get("Content-Type")) {
+				resp.Body.Close()
+				if resp.StatusCode != http.StatusOK {
+					o.errorf("Error: --sse: the server answered %s", resp.Status)
+					if o.Fail && resp.StatusCode >= 400 {
+						return exitHTTPError
+					}
+				} else {
+					o.errorf("Error: --sse: the response is %q, not text/event-stream", resp.Header.Get("Content-Type"))
+				}
+				return 1
+			}
+			reader := network.NewSSEReader(resp.Body)
+			for {
+				ev, readErr := reader.Next()
+				if readErr != nil {
+					err = readErr
+					break
+				}
+				network.PrintEvent(o.Stdout, ev, o.Out)
+			}
+			resp.Body.Close()
+			lastID = reader.LastEventID
+			if reader.Retry > 0 {
+				retry = reader.Retry
+			}
+		} else if result != nil {
+			result.Response.Body.Close()
+		}
+
+		if ctx.Err() != nil {
+			o.errorf("Transfer interrupted")
+			return exitInterrupted
+		}
+		if streamCtx.Err() != nil {
+			if !o.Quiet {
+				fmt.Fprintf(o.Stderr, "%sStream closed after --max-time %s%s\n", o.Err.GetAnsiCode("yellow"), maxTime, o.Err.ResetCode())
+			}
+			return 0
+		}
+		if attempt == 1 && requestErr != nil {
+			switch {
+			case errors.Is(requestErr, network.ErrMaxFileSize):
+				o.errorf("Error: %v", requestErr)
+			case opts.Verbose < network.VerboseLines:
+				// In verbose mode the request has already reported the failure.
+				o.errorf("Error executing request: %v", requestErr)
+			}
+			return 1
+		}
+
+		reason := "the server closed the stream"
+		if err != nil && !errors.Is(err, io.EOF) {
+			reason = err.Error()
+		}
+		if !o.Quiet {
+			fmt.Fprintf(o.Stderr, "%sConnection lost (%s); reconnecting in %s%s\n", o.Err.GetAnsiCode("yellow"), reason, retry, o.Err.ResetCode())
+		}
+		select {
+		case <-time.After(retry):
+		case <-streamCtx.Done():
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mclellac/hurl/config"
+	"github.com/mclellac/hurl/network"
+)
+
+// testOutput returns output options writing to stdout and stderr, without
+// colors.
+func testOutput(stdout, stderr *strings.Builder) outputOptions {
+	cfg := config.DefaultConfig()
+	cfg.Color = false
+	return outputOptions{Out: cfg, Err: cfg, Stdout: stdout, Stderr: stderr}
+}
+
+func TestStreamEventsReconnects(t *testing.T) {
+	var mu sync.Mutex
+	var lastIDs []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		lastIDs = append(lastIDs, r.Header.Get("Last-Event-ID"))
+		n := len(lastIDs)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "text/event-stream")
+		if n > 2 {
+			<-r.Context().Done() // Hold the stream open until --max-time
+			return
+		}
+		fmt.Fprintf(w, "retry: 10\nid: %d\ndata: event %d\n\n", n, n)
+	}))
+	defer srv.Close()
+
+	var stdout, stderr strings.Builder
+	prepare := func() (network.RequestOptions, error) {
+		return network.RequestOptions{URL: srv.URL, Timeout: 500 * time.Millisecond}, nil
+	}
+	if code := streamEvents(context.Background(), testOutput(&stdout, &stderr), prepare); code != 0 {
+		t.Fatalf("streamEvents = %d, want 0; stderr:\n%s", code, stderr.String())
+	}
+	if want := "id: 1\nretry: 10\ndata: event 1\n\nid: 2\nretry: 10\ndata: event 2\n\n"; stdout.String() != want {
+		t.Errorf("stdout = %q, want %q", stdout.String(), want)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if got := strings.Join(lastIDs, ","); got != ",1,2" {
+		t.Errorf("Last-Event-ID headers = %q, want \",1,2\"", got)
+	}
+	if !strings.Contains(stderr.String(), "Stream closed after --max-time") {
+		t.Errorf("stderr = %q, want the --max-time message", stderr.String())
+	}
+}
+
+func TestStreamEventsFirstRequestErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		opts    network.RequestOptions
+		wantErr string
+	}{
+		{
+			name:    "not found",
+			handler: func(w http.ResponseWriter, r *http.Request) { http.NotFound(w, r) },
+			wantErr: "the server answered 404 Not Found",
+		},
+		{
+			name:    "not an event stream",
+			handler: func(w http.ResponseWriter, r *http.Request) { w.Header().Set("Content-Type", "text/plain") },
+			wantErr: `the response is "text/plain", not text/event-stream`,
+		},
+		{
+			name: "too large",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/event-stream")
+				w.Header().Set("Content-Length", "100")
+				w.Write(make([]byte, 100))
+			},
+			opts:    network.RequestOptions{MaxFileSize: 10},
+			wantErr: "maximum file size",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+				tt.handler(w, r)
+			}))
+			defer srv.Close()
+
+			var stdout, stderr strings.Builder
+			prepare := func() (network.RequestOptions, error) {
+				opts := tt.opts
+				opts.URL = srv.URL
+				opts.Timeout = 5 * time.Second
+				return opts, nil
+			}
+			if code := streamEvents(context.Background(), testOutput(&stdout, &stderr), prepare); code != 1 {
+				t.Errorf("streamEvents = %d, want 1", code)
+			}
+			if !strings.Contains(stderr.String(), tt.wantErr) {
+				t.Errorf("stderr = %q, want %q", stderr.String(), tt.wantErr)
+			}
+			if n := requests.Load(); n != 1 {
+				t.Errorf("the server got %d requests, want 1 (no reconnection)", n)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// templateLineRE finds the "name:line:" position in text/template errors.
+var templateLineRE = regexp.MustCompile(`:(\d+):`)
+
+// templateBody parses a --data-template body and returns a function that
+// renders it. It is rendered for every request, so uuid and now give fresh
+// values each time. The template sees the environment as .Env (a missing
+// variable is an error) and can use these functions:
+//
+//	env "NAME"  the variable, or "" if it is not set
+//	uuid        a random (version 4) UUID
+//	now         the current time, e.g. {{now.Unix}} or {{now.Format "2006-01-02"}}
+func templateBody(name string, text []byte) (func() ([]byte, error), error) {
+	tmpl, err := template.New(name).
+		Option("missingkey=error").
+		Funcs(template.FuncMap{
+			"env":  os.Getenv,
+			"uuid": newUUID,
+			"now":  time.Now,
+		}).
+		Parse(string(text))
+	if err != nil {
+		return nil, templateError(err, text)
+	}
+
+	env := make(map[string]string)
+	for _, kv := range os.Environ() {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			env[k] = v
+		}
+	}
+	data := struct{ Env map[string]string }{env}
+
+	return func() ([]byte, error) {
+		var b bytes.Buffer
+		if err := tmpl.Execute(&b, data); err != nil {
+			return nil, templateError(err, text)
+		}
+		return b.Bytes(), nil
+	}, nil
+}
+
+// templateError adds the template line an error refers to, so the problem
+// can be found without counting lines.
+func templateError(err error, text []byte) error {
+	m := templateLineRE.FindStringSubmatch(err.Error())
+	if m == nil {
+		return err
+	}
+	n, _ := strconv.Atoi(m[1])
+	lines := strings.Split(string(text), "\n")
+	if n < 1 || n > len(lines) {
+		return err
+	}
+	return fmt.Errorf("%w\n  %d | %s", err, n, strings.TrimRight(lines[n-1], "\r"))
+}
+
+// newUUID returns a random version 4 UUID.
+func newUUID() string {
+	var u [16]byte
+	rand.Read(u[:])
+	u[6] = u[6]&0x0f | 0x40
+	u[8] = u[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:])
+}
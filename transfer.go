@@ -0,0 +1,264 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/mclellac/hurl/config"
+	"github.com/mclellac/hurl/display"
+	"github.com/mclellac/hurl/network"
+	"golang.org/x/term"
+)
+
+// outputOptions controls what is written for each transfer.
+type outputOptions struct {
+	Fail        bool          // Treat HTTP error statuses as failures (-f)
+	Include     bool          // Write the status line and headers before the body (-i)
+	HeadersOnly bool          // Write no body (--show-headers-only)
+	Options     bool          // Write a summary of Allow and CORS headers instead of the response (--options)
+	Progress    bool          // Show a progress bar on a terminal stderr while writing to a file
+	Pretty      bool          // Pretty-print JSON bodies (--pretty)
+	JSON        bool          // Write the whole exchange as one JSON document (--json-output)
+	Timings     bool          // Print the timing breakdown to stderr (--timings, or -v and up)
+	WriteOut    string        // Format printed after the transfer (-w)
+	ColorMode   string        // --color mode, resolved again for output files
+	Out         config.Config // Colors for stdout
+	Err         config.Config // Colors for stderr
+	Stdout      io.Writer     // Where output meant for stdout goes
+	Stderr      io.Writer     // Where messages meant for stderr go
+	Diagnostics io.Writer     // Where verbose diagnostics go (--trace-file); Stderr if nil
+	Trace       io.Writer     // Where the --trace-ascii dump goes; nil for none
+
+	RemoveOnError bool   // Delete the output file when the transfer fails (--remove-on-error)
+	ETagSave      string // File to store the response ETag in (--etag-save)
+	RemoteTime    bool   // Give the output file the response's Last-Modified time (-R)
+	Quiet         bool   // Suppress informational messages such as "Not modified" (-s)
+	RemoteName    bool   // Files are named after the URL or Content-Disposition when no -o is given (-O)
+	HeaderName    bool   // With RemoteName, prefer the Content-Disposition file name (-J)
+	OutputDir     string // Directory for files named by -O (--output-dir)
+	CertOut       string // File to write the server's leaf certificate to as PEM (--cert-out)
+	CertCheck     bool   // Warn if the server's certificate has expired or expires within CertWarnDays
+	CertWarnDays  int    // Days before expiry to start warning; 0 warns only once the certificate has expired
+
+	Metrics *metricsWriter // Where --repeat writes each request's timings (--metrics-out)
+}
+
+// errorf prints an error message in red to o.Stderr, unless errors are
+// silenced.
+func (o outputOptions) errorf(format string, args ...any) {
+	if showErrors {
+		fmt.Fprintf(o.Stderr, "%s%s%s\n", o.Err.GetAnsiCode("red"), fmt.Sprintf(format, args...), o.Err.ResetCode())
+	}
+}
+
+// transfer performs one request and writes its output: the body (and
+// headers with -i) to o.Stdout or the output file, then timings and
+// write-out. Errors are reported on o.Stderr; the returned exit code is 0 on
+// success, exitHTTPError for an HTTP error with --fail, exitInterrupted when
+// ctx is cancelled, and 1 for anything else.
+func transfer(ctx context.Context, opts network.RequestOptions, output string, o outputOptions) int {
+	result, err := network.DoContext(ctx, opts)
+	if result != nil {
+		defer result.Response.Body.Close()
+	}
+	if err != nil && ctx.Err() != nil {
+		o.errorf("Transfer interrupted")
+		return exitInterrupted
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, network.ErrMaxFileSize):
+			o.errorf("Error: %v", err)
+		case opts.Verbose < network.VerboseLines:
+			// In verbose mode Fetch has already reported the failure.
+			o.errorf("Error executing request: %v", err)
+		}
+		return 1
+	}
+	resp := result.Response
+	// The summary goes wherever the rest of the verbose output went.
+	summary := func() {
+		if opts.Verbose >= network.VerboseLines {
+			diag := opts.Diagnostics
+			if diag == nil {
+				diag = o.Stderr
+			}
+			display.PrintSummary(diag, result, opts.Config)
+		}
+	}
+
+	// A resumed download the server has no more bytes for is already complete.
+	complete := opts.ResumeFrom > 0 && resp.StatusCode == http.StatusRequestedRangeNotSatisfiable
+
+	// With --fail, an HTTP error status suppresses the body, like curl.
+	failed := o.Fail && resp.StatusCode >= 400 && !complete
+
+	// A name chosen by the server never replaces an existing file.
+	fromHeader := false
+	if output == "" && o.RemoteName && !failed {
+		name := dispositionFileName(resp.Header.Get("Content-Disposition"))
+		fromHeader = name != ""
+		if name == "" {
+			name = urlFileName(opts.URL)
+		}
+		if name == "" {
+			o.errorf("Error: -O: no file name in the URL or in a Content-Disposition header")
+			return 1
+		}
+		output = filepath.Join(o.OutputDir, name)
+	}
+
+	// A 304 to a conditional request means the copy in the output file is
+	// current, so the file is not touched.
+	notModified := resp.StatusCode == http.StatusNotModified
+	if notModified && !o.Quiet && !o.JSON {
+		if output != "" {
+			fmt.Fprintf(o.Stderr, "%sNot modified: %s is up to date%s\n", o.Err.GetAnsiCode(o.Err.StatusSuccessColor), output, o.Err.ResetCode())
+		} else {
+			fmt.Fprintf(o.Stderr, "%sNot modified%s\n", o.Err.GetAnsiCode(o.Err.StatusSuccessColor), o.Err.ResetCode())
+		}
+	}
+
+	if o.JSON && !failed {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil && ctx.Err() != nil {
+			o.errorf("Transfer interrupted")
+			return exitInterrupted
+		}
+		if err != nil {
+			o.errorf("Error reading response body: %v", err)
+			return 1
+		}
+		result.Timings.Finish()
+		summary()
+		if err := display.WriteExchange(o.Stdout, display.NewExchange(resp, body, result.Timings, result.Info)); err != nil {
+			o.errorf("Error writing JSON output: %v", err)
+			return 1
+		}
+		return 0
+	}
+
+	var out io.Writer = o.Stdout
+	var size int64
+	if !failed && !complete && !(notModified && output != "") {
+		// The output is written even in silent mode; -s only hides diagnostics.
+		var outFile *os.File
+		bodyCfg := o.Out
+		if output != "" {
+			// A resumed transfer is appended; if the server ignored the
+			// range and sent everything, the file is started over.
+			flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+			if opts.ResumeFrom > 0 && resp.StatusCode == http.StatusPartialContent {
+				flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+			}
+			if fromHeader {
+				flags |= os.O_EXCL
+			}
+			outFile, err = os.OpenFile(output, flags, 0666)
+			if fromHeader && errors.Is(err, fs.ErrExist) {
+				o.errorf("Error: %s already exists; not overwriting it with the Content-Disposition file name", output)
+				return 1
+			}
+			if err != nil {
+				o.errorf("Error creating output file: %v", err)
+				return 1
+			}
+			out = outFile
+			bodyCfg.Color = colorEnabled(o.ColorMode, outFile)
+		}
+		if o.Options {
+			display.PrintOptions(out, resp, bodyCfg)
+		} else if o.Include {
+			writeHead(out, resp, bodyCfg)
+		}
+		// Show progress on the terminal while the body goes to a file.
+		var bodyOut io.Writer = out
+		var progress *display.Progress
+		if outFile != nil && o.Progress && !o.HeadersOnly && !o.Options && term.IsTerminal(int(os.Stderr.Fd())) {
+			progress = display.NewProgress(os.Stderr, resp.ContentLength)
+			bodyOut = io.MultiWriter(out, progress)
+		}
+		var err error
+		if !o.HeadersOnly && !o.Options {
+			// With --show-headers-only and --options the body is closed unread.
+			size, err = writeBody(bodyOut, resp, o.Pretty, bodyCfg)
+		}
+		if progress != nil {
+			progress.Finish()
+		}
+		if outFile != nil {
+			if closeErr := outFile.Close(); err == nil {
+				err = closeErr
+			}
+		}
+		if err != nil {
+			// A file cut off at --max-filesize is never wanted.
+			if outFile != nil && (o.RemoveOnError || errors.Is(err, network.ErrMaxFileSize)) {
+				os.Remove(output)
+			}
+			if ctx.Err() != nil {
+				o.errorf("Transfer interrupted")
+				return exitInterrupted
+			}
+			if errors.Is(err, network.ErrMaxFileSize) {
+				o.errorf("Error: %v", err)
+				return 1
+			}
+			o.errorf("Error writing response body: %v", err)
+			return 1
+		}
+		if outFile != nil && o.RemoteTime {
+			if err := setRemoteTime(output, resp); err != nil {
+				o.errorf("Error: -R: %v", err)
+				return 1
+			}
+		}
+	}
+
+	result.Timings.Finish()
+	summary()
+	if o.ETagSave != "" && !failed {
+		if err := saveETag(o.ETagSave, resp); err != nil {
+			o.errorf("Error: --etag-save: %v", err)
+			return 1
+		}
+	}
+	if o.CertCheck && len(result.Info.PeerCerts) > 0 && showErrors {
+		warnCertExpiry(o, result.Info.PeerCerts[0], o.CertWarnDays)
+	}
+	if o.CertOut != "" {
+		if err := saveCertificate(o.CertOut, result.Info.PeerCerts); err != nil {
+			o.errorf("Error: --cert-out: %v", err)
+			return 1
+		}
+	}
+	if o.Timings {
+		display.PrintTimings(o.Stderr, result.Timings, o.Err)
+	}
+
+	if o.WriteOut != "" {
+		unknown := display.WriteOut(o.Stdout, o.WriteOut, display.WriteOutData{
+			Response:     resp,
+			Timings:      result.Timings,
+			Info:         result.Info,
+			SizeDownload: size,
+		})
+		for _, name := range unknown {
+			if showErrors {
+				fmt.Fprintf(o.Stderr, "%sWarning: unknown --write-out variable %%{%s}%s\n", o.Err.GetAnsiCode("yellow"), name, o.Err.ResetCode())
+			}
+		}
+	}
+
+	if failed {
+		o.errorf("Error: the requested URL returned error: %s", resp.Status)
+		return exitHTTPError
+	}
+	return 0
+}
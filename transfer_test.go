@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/mclellac/hurl/network"
+)
+
+func TestTransferFail(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/"))
+		w.WriteHeader(status)
+		w.Write([]byte("body of " + r.URL.Path))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		status   int
+		fail     bool
+		wantCode int
+		wantBody bool
+	}{
+		{200, true, 0, true},
+		{404, true, exitHTTPError, false},
+		{500, true, exitHTTPError, false},
+		{404, false, 0, true},
+		{500, false, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.status)+" fail="+strconv.FormatBool(tt.fail), func(t *testing.T) {
+			var stdout, stderr strings.Builder
+			o := testOutput(&stdout, &stderr)
+			o.Fail = tt.fail
+			opts := network.RequestOptions{URL: srv.URL + "/" + strconv.Itoa(tt.status)}
+			if code := transfer(context.Background(), opts, "", o); code != tt.wantCode {
+				t.Errorf("transfer = %d, want %d", code, tt.wantCode)
+			}
+			wantBody := ""
+			if tt.wantBody {
+				wantBody = "body of /" + strconv.Itoa(tt.status)
+			}
+			if stdout.String() != wantBody {
+				t.Errorf("stdout = %q, want %q", stdout.String(), wantBody)
+			}
+			if failed := strings.Contains(stderr.String(), "the requested URL returned error: "+strconv.Itoa(tt.status)); failed == tt.wantBody {
+				t.Errorf("stderr = %q", stderr.String())
+			}
+
+			// With an output file, a failure leaves no file behind.
+			output := filepath.Join(t.TempDir(), "out")
+			if code := transfer(context.Background(), opts, output, o); code != tt.wantCode {
+				t.Errorf("transfer to a file = %d, want %d", code, tt.wantCode)
+			}
+			data, err := os.ReadFile(output)
+			switch {
+			case tt.wantBody && string(data) != wantBody:
+				t.Errorf("output file = %q, %v; want %q", data, err, wantBody)
+			case !tt.wantBody && !os.IsNotExist(err):
+				t.Errorf("output file exists after a failure (%q, %v)", data, err)
+			}
+		})
+	}
+}
+
+func TestTransferSizeDownloadWithPretty(t *testing.T) {
+	const body = `{"a":[1,2]}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	var stdout, stderr strings.Builder
+	o := testOutput(&stdout, &stderr)
+	o.Pretty = true
+	o.WriteOut = "|%{size_download}" // Counts the body as received, not as printed
+	if code := transfer(context.Background(), network.RequestOptions{URL: srv.URL}, "", o); code != 0 {
+		t.Fatalf("transfer = %d, stderr %q", code, stderr.String())
+	}
+	printed, size, _ := strings.Cut(stdout.String(), "|")
+	if printed == body || size != strconv.Itoa(len(body)) {
+		t.Errorf("stdout = %q, want the pretty-printed body and size_download %d", stdout.String(), len(body))
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/mclellac/hurl/display"
+	"github.com/mclellac/hurl/network"
+)
+
+// webSocketCloseTimeout is how long --ws-probe waits for the server to
+// answer its close frame.
+const webSocketCloseTimeout = 2 * time.Second
+
+// probeWebSocket sends a WebSocket opening handshake for opts.URL (ws://
+// and wss:// URLs are sent as http:// and https://), prints the response
+// and whether it accepted the upgrade, and then closes the connection
+// with a close frame. It returns 0 if the upgrade was accepted.
+func probeWebSocket(ctx context.Context, opts network.RequestOptions, o outputOptions) int {
+	if rest, ok := strings.CutPrefix(opts.URL, "ws://"); ok {
+		opts.URL = "http://" + rest
+	} else if rest, ok := strings.CutPrefix(opts.URL, "wss://"); ok {
+		opts.URL = "https://" + rest
+	}
+	key, err := network.NewWebSocketKey()
+	if err != nil {
+		o.errorf("Error: --ws-probe: %v", err)
+		return 1
+	}
+	opts.DefaultHeaders = append(append([]string(nil), opts.DefaultHeaders...), network.WebSocketHeaders(key)...)
+	// http.Client's own timeout hides the upgraded connection's Write
+	// method, so --max-time is applied through the context instead.
+	reqCtx := ctx
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		reqCtx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+		opts.Timeout = 0
+	}
+
+	result, err := network.DoContext(reqCtx, opts)
+	if result != nil {
+		defer result.Response.Body.Close()
+	}
+	if err != nil && ctx.Err() != nil {
+		o.errorf("Transfer interrupted")
+		return exitInterrupted
+	}
+	if err != nil {
+		if opts.Verbose < network.VerboseLines {
+			o.errorf("Error executing request: %v", err)
+		}
+		return 1
+	}
+
+	resp := result.Response
+	problem := network.CheckWebSocketUpgrade(resp)
+	display.PrintWebSocketProbe(o.Stdout, resp, problem, o.Out)
+	if problem != nil {
+		if o.Fail && resp.StatusCode >= 400 {
+			return exitHTTPError
+		}
+		return 1
+	}
+	conn, ok := resp.Body.(io.ReadWriteCloser)
+	if !ok {
+		o.errorf("Error: --ws-probe: the upgraded connection cannot be written to, so it was not closed cleanly")
+		return 1
+	}
+	if err := network.CloseWebSocket(conn, webSocketCloseTimeout); err != nil {
+		o.errorf("Error: --ws-probe: %v", err)
+		return 1
+	}
+	return 0
+}